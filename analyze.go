@@ -0,0 +1,286 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// 目录分析选项
+type AnalyzeOptions struct {
+	Walk WalkOptions
+	Top  int    // --top 每个排行榜显示的条目数
+	By   string // --by 分组方式: ext, dir, owner
+
+	Tree     bool // --tree 显示目录占用树
+	MaxDepth int  // --max-depth 树的最大显示深度，-1 表示不限制
+	Apparent bool // --apparent 使用表观大小而不是磁盘占用
+
+	Workers int // 并发读取目录的工作线程数，来自配置的 max_workers
+}
+
+// 分析中记录的文件
+type analyzedFile struct {
+	Path    string
+	Size    int64
+	ModTime time.Time
+}
+
+// 分组统计
+type groupStat struct {
+	Name  string
+	Count int
+	Size  int64
+}
+
+// 目录分析结果
+type analyzeResult struct {
+	Root      string
+	FileCount int
+	DirCount  int
+	TotalSize int64
+
+	Groups     map[string]*groupStat // 按 --by 分组
+	DirSizes   map[string]int64      // 每个子目录的累计大小
+	Largest    []analyzedFile
+	Oldest     []analyzedFile
+	Newest     []analyzedFile
+	EmptyFiles []string
+	EmptyDirs  []string
+}
+
+// 文件大小统计
+func analyzeDirectory(dir string, options *AnalyzeOptions) {
+	fmt.Printf("分析目录: %s\n", dir)
+
+	result, stats, err := collectAnalysis(dir, options)
+	if err != nil {
+		fmt.Printf("分析失败: %v\n", err)
+		return
+	}
+
+	printAnalysis(result, options)
+	printWalkStats(stats)
+}
+
+// 遍历目录并收集统计信息
+func collectAnalysis(dir string, options *AnalyzeOptions) (*analyzeResult, *WalkStats, error) {
+	dir = filepath.Clean(dir)
+	result := &analyzeResult{
+		Root:     dir,
+		Groups:   make(map[string]*groupStat),
+		DirSizes: make(map[string]int64),
+	}
+	childCount := make(map[string]int)
+
+	stats, err := scanTree(dir, options, func(path string, info os.FileInfo) error {
+		if path != dir {
+			childCount[filepath.Dir(path)]++
+		}
+
+		if info.IsDir() {
+			result.DirCount++
+			childCount[path] += 0
+			return nil
+		}
+
+		result.FileCount++
+		result.TotalSize += info.Size()
+
+		group := analyzeGroupKey(dir, path, info, options.By)
+		if result.Groups[group] == nil {
+			result.Groups[group] = &groupStat{Name: group}
+		}
+		result.Groups[group].Count++
+		result.Groups[group].Size += info.Size()
+
+		// 按相对路径逐级累加到所有上级子目录，根目录为 "." 等形式时不能用前缀判断
+		if rel, err := filepath.Rel(dir, filepath.Dir(path)); err == nil && rel != "." {
+			parent := dir
+			for _, name := range strings.Split(rel, string(filepath.Separator)) {
+				parent = filepath.Join(parent, name)
+				result.DirSizes[parent] += info.Size()
+			}
+		}
+
+		if info.Size() == 0 {
+			result.EmptyFiles = append(result.EmptyFiles, path)
+		}
+
+		file := analyzedFile{Path: path, Size: info.Size(), ModTime: info.ModTime()}
+		result.Largest = insertTopFile(result.Largest, file, options.Top, func(a, b analyzedFile) bool { return a.Size > b.Size })
+		result.Oldest = insertTopFile(result.Oldest, file, options.Top, func(a, b analyzedFile) bool { return a.ModTime.Before(b.ModTime) })
+		result.Newest = insertTopFile(result.Newest, file, options.Top, func(a, b analyzedFile) bool { return a.ModTime.After(b.ModTime) })
+		return nil
+	})
+	if err != nil {
+		return nil, stats, err
+	}
+
+	// 读取失败的目录不算作空目录
+	unreadable := make(map[string]bool)
+	for _, walkErr := range stats.Errors {
+		unreadable[walkErr.Path] = true
+	}
+	for path, count := range childCount {
+		if count == 0 && path != dir && !unreadable[path] {
+			result.EmptyDirs = append(result.EmptyDirs, path)
+		}
+	}
+	sort.Strings(result.EmptyFiles)
+	sort.Strings(result.EmptyDirs)
+
+	return result, stats, nil
+}
+
+// 并发遍历目录，在终端上显示已扫描的条目数
+func scanTree(dir string, options *AnalyzeOptions, fn walkFunc) (*WalkStats, error) {
+	progress := newProgress("扫描", 0, 0)
+	defer progress.Finish()
+
+	return walkTreeParallel(dir, &options.Walk, options.Workers, func(path string, info os.FileInfo) error {
+		if !info.IsDir() {
+			progress.AddFile(info.Size())
+		}
+		return fn(path, info)
+	})
+}
+
+// 计算文件所属的分组
+func analyzeGroupKey(root string, path string, info os.FileInfo, by string) string {
+	switch by {
+	case "dir":
+		// 按根目录下的第一级子目录分组
+		rel, err := filepath.Rel(root, path)
+		if err != nil {
+			return "."
+		}
+		parts := strings.SplitN(filepath.ToSlash(rel), "/", 2)
+		if len(parts) < 2 {
+			return "."
+		}
+		return parts[0]
+	case "owner":
+		return fileOwner(info)
+	default:
+		ext := strings.ToLower(filepath.Ext(path))
+		if ext == "" {
+			return "(无扩展名)"
+		}
+		return ext
+	}
+}
+
+// 将文件插入有序的前N列表，less 为 true 表示 a 应排在 b 之前
+func insertTopFile(list []analyzedFile, file analyzedFile, n int, less func(a, b analyzedFile) bool) []analyzedFile {
+	if n <= 0 {
+		return list
+	}
+	if len(list) == n && !less(file, list[n-1]) {
+		return list
+	}
+
+	index := sort.Search(len(list), func(i int) bool { return less(file, list[i]) })
+	if len(list) < n {
+		list = append(list, analyzedFile{})
+	}
+	copy(list[index+1:], list[index:])
+	list[index] = file
+	return list
+}
+
+// 打印分析结果
+func printAnalysis(result *analyzeResult, options *AnalyzeOptions) {
+	fmt.Printf("结果:\n")
+	fmt.Printf("  文件数: %d\n", result.FileCount)
+	fmt.Printf("  目录数: %d\n", result.DirCount)
+	fmt.Printf("  总大小: %.2f MB\n", float64(result.TotalSize)/1024/1024)
+
+	groupTitles := map[string]string{
+		"ext":   "按扩展名",
+		"dir":   "按子目录",
+		"owner": "按所有者",
+	}
+	groups := make([]*groupStat, 0, len(result.Groups))
+	for _, group := range result.Groups {
+		groups = append(groups, group)
+	}
+	sort.Slice(groups, func(i, j int) bool {
+		if groups[i].Size != groups[j].Size {
+			return groups[i].Size > groups[j].Size
+		}
+		return groups[i].Name < groups[j].Name
+	})
+
+	fmt.Printf("\n%s统计:\n", groupTitles[options.By])
+	for i, group := range groups {
+		if i >= options.Top {
+			fmt.Printf("  ... 其余 %d 项\n", len(groups)-options.Top)
+			break
+		}
+		fmt.Printf("  %-20s %8d 个文件 %12s  %5.1f%%\n", group.Name, group.Count, formatSize(group.Size), percentOf(group.Size, result.TotalSize))
+	}
+
+	fmt.Printf("\n最大的 %d 个文件:\n", len(result.Largest))
+	for _, file := range result.Largest {
+		fmt.Printf("  %12s  %s\n", formatSize(file.Size), file.Path)
+	}
+
+	dirs := make([]string, 0, len(result.DirSizes))
+	for path := range result.DirSizes {
+		dirs = append(dirs, path)
+	}
+	sort.Slice(dirs, func(i, j int) bool {
+		if result.DirSizes[dirs[i]] != result.DirSizes[dirs[j]] {
+			return result.DirSizes[dirs[i]] > result.DirSizes[dirs[j]]
+		}
+		return dirs[i] < dirs[j]
+	})
+	if len(dirs) > options.Top {
+		dirs = dirs[:options.Top]
+	}
+	fmt.Printf("\n最大的 %d 个子目录:\n", len(dirs))
+	for _, path := range dirs {
+		fmt.Printf("  %12s  %s\n", formatSize(result.DirSizes[path]), path)
+	}
+
+	fmt.Printf("\n最旧的 %d 个文件:\n", len(result.Oldest))
+	for _, file := range result.Oldest {
+		fmt.Printf("  %s  %s\n", file.ModTime.Format("2006-01-02 15:04:05"), file.Path)
+	}
+
+	fmt.Printf("\n最新的 %d 个文件:\n", len(result.Newest))
+	for _, file := range result.Newest {
+		fmt.Printf("  %s  %s\n", file.ModTime.Format("2006-01-02 15:04:05"), file.Path)
+	}
+
+	printPathList("空文件", result.EmptyFiles, options.Top)
+	printPathList("空目录", result.EmptyDirs, options.Top)
+}
+
+// 打印路径列表，最多显示 limit 项
+func printPathList(title string, paths []string, limit int) {
+	if len(paths) == 0 {
+		return
+	}
+
+	fmt.Printf("\n%s (%d):\n", title, len(paths))
+	for i, path := range paths {
+		if i >= limit {
+			fmt.Printf("  ... 其余 %d 项\n", len(paths)-limit)
+			break
+		}
+		fmt.Printf("  %s\n", path)
+	}
+}
+
+// 计算百分比
+func percentOf(part int64, total int64) float64 {
+	if total == 0 {
+		return 0
+	}
+	return float64(part) * 100 / float64(total)
+}
@@ -0,0 +1,293 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// 语言的注释语法
+type commentSyntax struct {
+	Line  []string    // 行注释前缀
+	Block [][2]string // 块注释的开始和结束标记
+}
+
+var (
+	cStyleComments = commentSyntax{Line: []string{"//"}, Block: [][2]string{{"/*", "*/"}}}
+	hashComments   = commentSyntax{Line: []string{"#"}}
+	markupComments = commentSyntax{Block: [][2]string{{"<!--", "-->"}}}
+)
+
+// 各语言的注释语法，语言名称来自 textExtensions，未列出的语言没有注释
+var languageComments = map[string]commentSyntax{
+	"Go":           cStyleComments,
+	"Java":         cStyleComments,
+	"C":            cStyleComments,
+	"C++":          cStyleComments,
+	"C/C++ Header": cStyleComments,
+	"C++ Header":   cStyleComments,
+	"JavaScript":   cStyleComments,
+	"TypeScript":   cStyleComments,
+	"Rust":         cStyleComments,
+	"Swift":        cStyleComments,
+	"Kotlin":       cStyleComments,
+	"Scala":        cStyleComments,
+	"Objective-C":  cStyleComments,
+	"CSS":          {Block: [][2]string{{"/*", "*/"}}},
+	"PHP":          {Line: []string{"//", "#"}, Block: [][2]string{{"/*", "*/"}}},
+	"SQL":          {Line: []string{"--"}, Block: [][2]string{{"/*", "*/"}}},
+	"Lua":          {Line: []string{"--"}, Block: [][2]string{{"--[[", "]]"}}},
+	"Python":       hashComments,
+	"Shell":        hashComments,
+	"Fish":         hashComments,
+	"YAML":         hashComments,
+	"R":            hashComments,
+	"Perl":         hashComments,
+	"TOML":         hashComments,
+	"Properties":   {Line: []string{"#", "!"}},
+	"Config":       {Line: []string{"#", ";"}},
+	"INI":          {Line: []string{";", "#"}},
+	"Ruby":         {Line: []string{"#"}, Block: [][2]string{{"=begin", "=end"}}},
+	"PowerShell":   {Line: []string{"#"}, Block: [][2]string{{"<#", "#>"}}},
+	"Batch":        {Line: []string{"REM", "rem", "::"}},
+	"Vim Script":   {Line: []string{"\""}},
+	"Emacs Lisp":   {Line: []string{";"}},
+	"HTML":         markupComments,
+	"XML":          markupComments,
+	"Markdown":     markupComments,
+}
+
+// 单个语言的行数统计
+type LanguageStats struct {
+	Language string `json:"language"`
+	Files    int    `json:"files"`
+	Blank    int    `json:"blank"`
+	Comment  int    `json:"comment"`
+	Code     int    `json:"code"`
+}
+
+// 统计目录中各语言的代码行数
+func countCode(dir string, options *AnalyzeOptions, jsonOutput bool) {
+	var files []string
+	stats, err := scanTree(dir, options, func(path string, info os.FileInfo) error {
+		if info.Mode().IsRegular() && fileLanguage(path) != "" {
+			files = append(files, path)
+		}
+		return nil
+	})
+	if err != nil {
+		fmt.Printf("分析失败: %v\n", err)
+		return
+	}
+
+	languages := countFilesByLanguage(files, options.Workers, stats)
+
+	if jsonOutput {
+		if languages == nil {
+			languages = []*LanguageStats{}
+		}
+		data, err := json.MarshalIndent(languages, "", "  ")
+		if err != nil {
+			fmt.Printf("序列化结果失败: %v\n", err)
+			return
+		}
+		fmt.Println(string(data))
+		printWalkWarnings(stats)
+		return
+	}
+
+	fmt.Printf("代码统计: %s\n", dir)
+	printLanguageTable(languages)
+	printWalkStats(stats)
+}
+
+// 根据扩展名获取语言
+func fileLanguage(path string) string {
+	return textExtensions[strings.ToLower(filepath.Ext(path))]
+}
+
+// 并发统计文件行数并按语言汇总，按代码行数降序返回
+func countFilesByLanguage(files []string, workers int, stats *WalkStats) []*LanguageStats {
+	if workers < 1 {
+		workers = 1
+	}
+
+	byLanguage := make(map[string]*LanguageStats)
+	filesChan := make(chan string)
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for path := range filesChan {
+				language := fileLanguage(path)
+				blank, comment, code, err := countFileLines(path, languageComments[language])
+
+				mu.Lock()
+				if err != nil {
+					stats.Errors = append(stats.Errors, WalkError{Path: path, Err: err})
+				} else {
+					if byLanguage[language] == nil {
+						byLanguage[language] = &LanguageStats{Language: language}
+					}
+					lang := byLanguage[language]
+					lang.Files++
+					lang.Blank += blank
+					lang.Comment += comment
+					lang.Code += code
+				}
+				mu.Unlock()
+			}
+		}()
+	}
+
+	for _, path := range files {
+		filesChan <- path
+	}
+	close(filesChan)
+	wg.Wait()
+
+	var languages []*LanguageStats
+	for _, lang := range byLanguage {
+		languages = append(languages, lang)
+	}
+	sort.Slice(languages, func(i, j int) bool {
+		if languages[i].Code != languages[j].Code {
+			return languages[i].Code > languages[j].Code
+		}
+		return languages[i].Language < languages[j].Language
+	})
+	return languages
+}
+
+// 统计单个文件的空行、注释行和代码行
+func countFileLines(path string, syntax commentSyntax) (blank int, comment int, code int, err error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return 0, 0, 0, err
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 64*1024), 16*1024*1024)
+
+	blockEnd := "" // 非空表示处于块注释中
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			blank++
+			continue
+		}
+
+		hasCode, hasComment := false, blockEnd != ""
+		rest := line
+		for rest != "" {
+			if blockEnd != "" {
+				idx := strings.Index(rest, blockEnd)
+				if idx < 0 {
+					break
+				}
+				rest = strings.TrimSpace(rest[idx+len(blockEnd):])
+				blockEnd = ""
+				continue
+			}
+
+			start, end, pos := findBlockStart(rest, syntax.Block)
+			if pos == 0 {
+				hasComment = true
+				blockEnd = end
+				rest = rest[len(start):]
+				continue
+			}
+
+			if hasLinePrefix(rest, syntax.Line) {
+				hasComment = true
+				break
+			}
+
+			// 注释之前有代码，继续检查同一行剩余部分是否开始了块注释。
+			// 行注释在块注释标记之前时，之后的内容都属于行注释
+			hasCode = true
+			if pos < 0 {
+				break
+			}
+			if idx := findLineComment(rest, syntax.Line); idx >= 0 && idx < pos {
+				break
+			}
+			rest = rest[pos:]
+		}
+
+		switch {
+		case hasCode:
+			code++
+		case hasComment:
+			comment++
+		default:
+			blank++
+		}
+	}
+
+	return blank, comment, code, scanner.Err()
+}
+
+// 检查是否以行注释开头
+func hasLinePrefix(line string, prefixes []string) bool {
+	for _, prefix := range prefixes {
+		if strings.HasPrefix(line, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// 查找最早出现的行注释前缀，未找到时返回 -1
+func findLineComment(line string, prefixes []string) int {
+	pos := -1
+	for _, prefix := range prefixes {
+		idx := strings.Index(line, prefix)
+		if idx >= 0 && (pos < 0 || idx < pos) {
+			pos = idx
+		}
+	}
+	return pos
+}
+
+// 查找最早出现的块注释开始标记，未找到时 pos 为 -1
+func findBlockStart(line string, blocks [][2]string) (start string, end string, pos int) {
+	pos = -1
+	for _, block := range blocks {
+		idx := strings.Index(line, block[0])
+		if idx >= 0 && (pos < 0 || idx < pos) {
+			start, end, pos = block[0], block[1], idx
+		}
+	}
+	return start, end, pos
+}
+
+// 打印语言统计表
+func printLanguageTable(languages []*LanguageStats) {
+	separator := strings.Repeat("-", 68)
+	fmt.Println(separator)
+	fmt.Printf("%-20s %10s %12s %12s %12s\n", "语言", "文件数", "空行", "注释", "代码")
+	fmt.Println(separator)
+
+	total := &LanguageStats{Language: "合计"}
+	for _, lang := range languages {
+		fmt.Printf("%-20s %10d %12d %12d %12d\n", lang.Language, lang.Files, lang.Blank, lang.Comment, lang.Code)
+		total.Files += lang.Files
+		total.Blank += lang.Blank
+		total.Comment += lang.Comment
+		total.Code += lang.Code
+	}
+
+	fmt.Println(separator)
+	fmt.Printf("%-20s %10d %12d %12d %12d\n", total.Language, total.Files, total.Blank, total.Comment, total.Code)
+	fmt.Println(separator)
+}
@@ -0,0 +1,86 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestCountFileLines(t *testing.T) {
+	tests := []struct {
+		name    string
+		syntax  commentSyntax
+		content string
+		blank   int
+		comment int
+		code    int
+	}{
+		{
+			name:    "行注释和空行",
+			syntax:  cStyleComments,
+			content: "// 注释\n\nint a;\n   \n",
+			blank:   2, comment: 1, code: 1,
+		},
+		{
+			name:    "代码后的行注释中出现块注释标记",
+			syntax:  cStyleComments,
+			content: "int a; // see /* note\nint b;\nint c;\n/* x */ int d;\n",
+			code:    4,
+		},
+		{
+			name:    "行内块注释",
+			syntax:  cStyleComments,
+			content: "int a; /* x */ int b;\n/* x */\n/* x */ /* y */\n",
+			comment: 2, code: 1,
+		},
+		{
+			name:    "跨行块注释",
+			syntax:  cStyleComments,
+			content: "/*\n * 说明\n\n */\nint a; /* 开始\n结束 */ int b;\n结束 */\n",
+			blank:   1, comment: 3, code: 3,
+		},
+		{
+			name:    "块注释结束后的代码",
+			syntax:  cStyleComments,
+			content: "/* 开始\n结束 */\n",
+			comment: 2,
+		},
+		{
+			name:    "Lua 块注释",
+			syntax:  languageComments["Lua"],
+			content: "-- 注释\n--[[ 开始\n结束 ]]\nlocal a = 1 --[[ 开始\n]] local b = 2\nlocal c = 3 -- 注释 --[[\nlocal d = 4\n",
+			comment: 3, code: 4,
+		},
+		{
+			name:    "多种行注释前缀",
+			syntax:  languageComments["PHP"],
+			content: "# 注释\n// 注释\n$a = 1; # 注释 /*\n$b = 2;\n",
+			comment: 2, code: 2,
+		},
+		{
+			name:    "没有注释语法",
+			syntax:  commentSyntax{},
+			content: "// 不是注释\n\n",
+			blank:   1, code: 1,
+		},
+	}
+
+	dir := t.TempDir()
+	for i, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(dir, string(rune('a'+i)))
+			if err := os.WriteFile(path, []byte(tt.content), 0644); err != nil {
+				t.Fatal(err)
+			}
+
+			blank, comment, code, err := countFileLines(path, tt.syntax)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if blank != tt.blank || comment != tt.comment || code != tt.code {
+				t.Errorf("空行/注释/代码 = %d/%d/%d, 期望 %d/%d/%d",
+					blank, comment, code, tt.blank, tt.comment, tt.code)
+			}
+		})
+	}
+}
@@ -0,0 +1,349 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+)
+
+// 快照中的文件记录
+type SnapshotFile struct {
+	Path    string    `json:"path"` // 相对于根目录，使用 / 分隔
+	Size    int64     `json:"size"`
+	ModTime time.Time `json:"mtime"`
+	Mode    string    `json:"mode"`
+	Hash    string    `json:"sha256,omitempty"`
+}
+
+// 目录快照
+type Snapshot struct {
+	Root    string          `json:"root"`
+	Created time.Time       `json:"created"`
+	Hashed  bool            `json:"hashed"`
+	Files   []*SnapshotFile `json:"files"`
+}
+
+// 记录目录快照并写入文件
+func snapshotDirectory(dir string, output string, withHash bool, options *AnalyzeOptions) {
+	snapshot, stats, err := takeSnapshot(dir, withHash, options)
+	if err != nil {
+		fmt.Printf("创建快照失败: %v\n", err)
+		return
+	}
+
+	data, err := json.MarshalIndent(snapshot, "", "  ")
+	if err != nil {
+		fmt.Printf("序列化快照失败: %v\n", err)
+		return
+	}
+	if err := os.WriteFile(output, data, 0644); err != nil {
+		fmt.Printf("保存快照失败: %v\n", err)
+		return
+	}
+
+	fmt.Printf("快照已保存: %s (%d 个文件)\n", output, len(snapshot.Files))
+	printWalkStats(stats)
+}
+
+// 遍历目录生成快照
+func takeSnapshot(dir string, withHash bool, options *AnalyzeOptions) (*Snapshot, *WalkStats, error) {
+	dir = filepath.Clean(dir)
+	snapshot := &Snapshot{Root: dir, Created: time.Now(), Hashed: withHash}
+
+	stats, err := scanTree(dir, options, func(path string, info os.FileInfo) error {
+		if info.IsDir() {
+			return nil
+		}
+		rel, err := filepath.Rel(dir, path)
+		if err != nil {
+			return err
+		}
+		snapshot.Files = append(snapshot.Files, &SnapshotFile{
+			Path:    filepath.ToSlash(rel),
+			Size:    info.Size(),
+			ModTime: info.ModTime(),
+			Mode:    info.Mode().String(),
+		})
+		return nil
+	})
+	if err != nil {
+		return nil, stats, err
+	}
+
+	sort.Slice(snapshot.Files, func(i, j int) bool { return snapshot.Files[i].Path < snapshot.Files[j].Path })
+
+	if withHash {
+		hashSnapshotFiles(dir, snapshot.Files, options.Workers, stats)
+	}
+	return snapshot, stats, nil
+}
+
+// 并发计算快照中文件的SHA-256，失败的文件记录到 stats
+func hashSnapshotFiles(dir string, files []*SnapshotFile, workers int, stats *WalkStats) {
+	if workers < 1 {
+		workers = 1
+	}
+
+	filesChan := make(chan *SnapshotFile)
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for file := range filesChan {
+				path := filepath.Join(dir, filepath.FromSlash(file.Path))
+				hash, err := calculateFileHash(path, "sha256")
+				if err != nil {
+					mu.Lock()
+					stats.Errors = append(stats.Errors, WalkError{Path: path, Err: err})
+					mu.Unlock()
+					continue
+				}
+				file.Hash = hash
+			}
+		}()
+	}
+
+	for _, file := range files {
+		filesChan <- file
+	}
+	close(filesChan)
+	wg.Wait()
+}
+
+// 读取快照文件
+func loadSnapshot(filename string) (*Snapshot, error) {
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		return nil, fmt.Errorf("读取快照失败: %v", err)
+	}
+
+	snapshot := &Snapshot{}
+	if err := json.Unmarshal(data, snapshot); err != nil {
+		return nil, fmt.Errorf("解析快照 %s 失败: %v", filename, err)
+	}
+	return snapshot, nil
+}
+
+// 快照差异
+type snapshotDiff struct {
+	Added    []*SnapshotFile
+	Removed  []*SnapshotFile
+	Modified [][2]*SnapshotFile // 旧记录, 新记录
+	Moved    [][2]*SnapshotFile
+	Growth   map[string]int64 // 每个目录的大小变化
+}
+
+// 比较快照与另一个快照或当前目录
+func diffSnapshots(oldFile string, target string, options *AnalyzeOptions) {
+	oldSnapshot, err := loadSnapshot(oldFile)
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+
+	var newSnapshot *Snapshot
+	if info, err := os.Stat(target); err == nil && info.IsDir() {
+		// 与当前目录比较时，如果旧快照包含哈希则同样计算哈希
+		var stats *WalkStats
+		newSnapshot, stats, err = takeSnapshot(target, oldSnapshot.Hashed, options)
+		if err != nil {
+			fmt.Printf("读取目录失败: %v\n", err)
+			return
+		}
+		defer printWalkStats(stats)
+	} else {
+		newSnapshot, err = loadSnapshot(target)
+		if err != nil {
+			fmt.Println(err)
+			return
+		}
+	}
+
+	fmt.Printf("比较: %s (%s) -> %s (%s)\n", oldFile, oldSnapshot.Created.Format("2006-01-02 15:04:05"),
+		target, newSnapshot.Created.Format("2006-01-02 15:04:05"))
+	printSnapshotDiff(compareSnapshots(oldSnapshot, newSnapshot), options.Top)
+}
+
+// 计算两个快照之间的差异
+func compareSnapshots(oldSnapshot *Snapshot, newSnapshot *Snapshot) *snapshotDiff {
+	diff := &snapshotDiff{Growth: make(map[string]int64)}
+
+	oldFiles := make(map[string]*SnapshotFile, len(oldSnapshot.Files))
+	for _, file := range oldSnapshot.Files {
+		oldFiles[file.Path] = file
+	}
+	newFiles := make(map[string]*SnapshotFile, len(newSnapshot.Files))
+	for _, file := range newSnapshot.Files {
+		newFiles[file.Path] = file
+	}
+
+	for _, file := range newSnapshot.Files {
+		old, ok := oldFiles[file.Path]
+		if !ok {
+			diff.Added = append(diff.Added, file)
+			continue
+		}
+		if old.Size != file.Size || !old.ModTime.Equal(file.ModTime) || old.Mode != file.Mode ||
+			(old.Hash != "" && file.Hash != "" && old.Hash != file.Hash) {
+			diff.Modified = append(diff.Modified, [2]*SnapshotFile{old, file})
+			diff.Growth[snapshotDir(file.Path)] += file.Size - old.Size
+		}
+	}
+	for _, file := range oldSnapshot.Files {
+		if _, ok := newFiles[file.Path]; !ok {
+			diff.Removed = append(diff.Removed, file)
+		}
+	}
+
+	// 内容相同的删除和新增视为移动：有哈希时比较哈希，否则比较大小和修改时间
+	moveKey := func(file *SnapshotFile) string {
+		if file.Hash != "" {
+			return file.Hash
+		}
+		if file.Size == 0 {
+			return ""
+		}
+		return fmt.Sprintf("%d@%d", file.Size, file.ModTime.UnixNano())
+	}
+	removedByKey := make(map[string][]*SnapshotFile)
+	for _, file := range diff.Removed {
+		if key := moveKey(file); key != "" {
+			removedByKey[key] = append(removedByKey[key], file)
+		}
+	}
+	moved := make(map[*SnapshotFile]bool)
+	var added []*SnapshotFile
+	for _, file := range diff.Added {
+		key := moveKey(file)
+		if candidates := removedByKey[key]; key != "" && len(candidates) > 0 {
+			diff.Moved = append(diff.Moved, [2]*SnapshotFile{candidates[0], file})
+			moved[candidates[0]] = true
+			removedByKey[key] = candidates[1:]
+			diff.Growth[snapshotDir(candidates[0].Path)] -= file.Size
+			diff.Growth[snapshotDir(file.Path)] += file.Size
+			continue
+		}
+		added = append(added, file)
+		diff.Growth[snapshotDir(file.Path)] += file.Size
+	}
+	diff.Added = added
+
+	var removed []*SnapshotFile
+	for _, file := range diff.Removed {
+		if !moved[file] {
+			removed = append(removed, file)
+			diff.Growth[snapshotDir(file.Path)] -= file.Size
+		}
+	}
+	diff.Removed = removed
+
+	return diff
+}
+
+// 快照路径所在的目录
+func snapshotDir(path string) string {
+	return filepath.ToSlash(filepath.Dir(filepath.FromSlash(path)))
+}
+
+// 打印快照差异
+func printSnapshotDiff(diff *snapshotDiff, top int) {
+	var addedSize, removedSize int64
+	for _, file := range diff.Added {
+		addedSize += file.Size
+	}
+	for _, file := range diff.Removed {
+		removedSize += file.Size
+	}
+
+	fmt.Printf("\n新增 %d 个文件 (%s):\n", len(diff.Added), formatSize(addedSize))
+	for _, file := range diff.Added {
+		fmt.Printf("  + %s (%s)\n", file.Path, formatSize(file.Size))
+	}
+
+	fmt.Printf("\n删除 %d 个文件 (%s):\n", len(diff.Removed), formatSize(removedSize))
+	for _, file := range diff.Removed {
+		fmt.Printf("  - %s (%s)\n", file.Path, formatSize(file.Size))
+	}
+
+	fmt.Printf("\n修改 %d 个文件:\n", len(diff.Modified))
+	for _, pair := range diff.Modified {
+		old, file := pair[0], pair[1]
+		var changes []string
+		if old.Size != file.Size {
+			changes = append(changes, fmt.Sprintf("大小 %s -> %s", formatSize(old.Size), formatSize(file.Size)))
+		}
+		if !old.ModTime.Equal(file.ModTime) {
+			changes = append(changes, fmt.Sprintf("修改时间 %s -> %s", old.ModTime.Format("2006-01-02 15:04:05"), file.ModTime.Format("2006-01-02 15:04:05")))
+		}
+		if old.Mode != file.Mode {
+			changes = append(changes, fmt.Sprintf("权限 %s -> %s", old.Mode, file.Mode))
+		}
+		if old.Hash != "" && file.Hash != "" && old.Hash != file.Hash {
+			changes = append(changes, "内容")
+		}
+		fmt.Printf("  * %s", file.Path)
+		for i, change := range changes {
+			if i == 0 {
+				fmt.Printf(": %s", change)
+			} else {
+				fmt.Printf(", %s", change)
+			}
+		}
+		fmt.Println()
+	}
+
+	fmt.Printf("\n移动 %d 个文件:\n", len(diff.Moved))
+	for _, pair := range diff.Moved {
+		fmt.Printf("  > %s -> %s\n", pair[0].Path, pair[1].Path)
+	}
+
+	dirs := make([]string, 0, len(diff.Growth))
+	for dir, growth := range diff.Growth {
+		if growth != 0 {
+			dirs = append(dirs, dir)
+		}
+	}
+	sort.Slice(dirs, func(i, j int) bool {
+		a, b := diff.Growth[dirs[i]], diff.Growth[dirs[j]]
+		if a < 0 {
+			a = -a
+		}
+		if b < 0 {
+			b = -b
+		}
+		if a != b {
+			return a > b
+		}
+		return dirs[i] < dirs[j]
+	})
+
+	fmt.Printf("\n目录大小变化 (前 %d 项):\n", top)
+	var total int64
+	for i, dir := range dirs {
+		growth := diff.Growth[dir]
+		total += growth
+		if i >= top {
+			continue
+		}
+		sign := "+"
+		if growth < 0 {
+			sign = "-"
+			growth = -growth
+		}
+		fmt.Printf("  %s%-12s %s\n", sign, formatSize(growth), dir)
+	}
+
+	sign := "+"
+	if total < 0 {
+		sign = "-"
+		total = -total
+	}
+	fmt.Printf("总变化: %s%s\n", sign, formatSize(total))
+}
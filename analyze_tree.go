@@ -0,0 +1,153 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// 占比条的宽度
+const duBarWidth = 20
+
+// 文件的设备号和inode，用于识别硬链接
+type fileKey struct {
+	Dev uint64
+	Ino uint64
+}
+
+// 磁盘占用树的节点
+type duNode struct {
+	Name     string
+	IsDir    bool
+	Size     int64
+	Children []*duNode
+}
+
+// 渲染按大小排序的目录占用树（类似 du/dust）
+func analyzeTree(dir string, options *AnalyzeOptions) {
+	root, linked, stats, err := buildDiskUsageTree(dir, options)
+	if err != nil {
+		fmt.Printf("分析失败: %v\n", err)
+		return
+	}
+
+	sizeKind := "磁盘占用"
+	if options.Apparent {
+		sizeKind = "表观大小"
+	}
+	fmt.Printf("目录占用树: %s (%s: %s)\n", dir, sizeKind, formatSize(root.Size))
+	if linked > 0 {
+		fmt.Printf("硬链接去重: %d 个文件只计算一次\n", linked)
+	}
+
+	printDiskUsageNode(root, root.Size, "", "", 0, options)
+	printWalkStats(stats)
+}
+
+// 遍历目录构建占用树，返回被去重的硬链接数量
+func buildDiskUsageTree(dir string, options *AnalyzeOptions) (*duNode, int, *WalkStats, error) {
+	dir = filepath.Clean(dir)
+	nodes := make(map[string]*duNode)
+	seen := make(map[fileKey]bool)
+	linked := 0
+
+	stats, err := scanTree(dir, options, func(path string, info os.FileInfo) error {
+		if key, ok := hardlinkKey(info); ok {
+			if seen[key] {
+				linked++
+				return nil
+			}
+			seen[key] = true
+		}
+
+		size := info.Size()
+		if !options.Apparent {
+			size = fileDiskUsage(info)
+		} else if info.IsDir() {
+			size = 0
+		}
+
+		node := &duNode{Name: info.Name(), IsDir: info.IsDir(), Size: size}
+		if path == dir {
+			node.Name = dir
+		} else if parent := nodes[filepath.Dir(path)]; parent != nil {
+			parent.Children = append(parent.Children, node)
+		}
+		if info.IsDir() {
+			nodes[path] = node
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, 0, stats, err
+	}
+
+	root := nodes[dir]
+	if root == nil {
+		return nil, 0, stats, fmt.Errorf("%s 不是目录", dir)
+	}
+	sumDiskUsage(root)
+	return root, linked, stats, nil
+}
+
+// 累加子节点大小并按大小降序排序
+func sumDiskUsage(node *duNode) int64 {
+	for _, child := range node.Children {
+		node.Size += sumDiskUsage(child)
+	}
+	sort.Slice(node.Children, func(i, j int) bool {
+		if node.Children[i].Size != node.Children[j].Size {
+			return node.Children[i].Size > node.Children[j].Size
+		}
+		return node.Children[i].Name < node.Children[j].Name
+	})
+	return node.Size
+}
+
+// 递归打印节点，prefix 为当前行的树形前缀，childPrefix 为子节点继承的前缀
+func printDiskUsageNode(node *duNode, total int64, prefix string, childPrefix string, depth int, options *AnalyzeOptions) {
+	name := node.Name
+	if node.IsDir && depth > 0 {
+		name += string(filepath.Separator)
+	}
+	percent := percentOf(node.Size, total)
+	fmt.Printf("%10s %s %5.1f%%  %s%s\n", formatSize(node.Size), percentBar(percent), percent, prefix, name)
+
+	if options.MaxDepth >= 0 && depth >= options.MaxDepth {
+		return
+	}
+
+	children := node.Children
+	var restSize int64
+	if len(children) > options.Top {
+		for _, child := range children[options.Top:] {
+			restSize += child.Size
+		}
+		children = children[:options.Top]
+	}
+
+	for i, child := range children {
+		last := i == len(children)-1 && restSize == 0
+		branch, next := "├── ", "│   "
+		if last {
+			branch, next = "└── ", "    "
+		}
+		printDiskUsageNode(child, total, childPrefix+branch, childPrefix+next, depth+1, options)
+	}
+
+	if restSize > 0 {
+		percent := percentOf(restSize, total)
+		fmt.Printf("%10s %s %5.1f%%  %s└── (其余 %d 项)\n", formatSize(restSize), percentBar(percent), percent, childPrefix, len(node.Children)-options.Top)
+	}
+}
+
+// 生成占比条
+func percentBar(percent float64) string {
+	filled := int(percent/100*duBarWidth + 0.5)
+	if filled > duBarWidth {
+		filled = duBarWidth
+	}
+	return strings.Repeat("█", filled) + strings.Repeat("░", duBarWidth-filled)
+}
@@ -0,0 +1,63 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+)
+
+// 为长时间运行的命令创建 context：收到 SIGINT/SIGTERM 或超过 timeout 后取消，timeout 为 0 表示不限制
+func commandContext(timeout time.Duration) (context.Context, context.CancelFunc) {
+	parent, cancelTimeout := context.WithCancel(context.Background())
+	if timeout > 0 {
+		parent, cancelTimeout = context.WithTimeout(context.Background(), timeout)
+	}
+
+	ctx, cancel := interruptContext(parent)
+	return ctx, func() {
+		cancel()
+		cancelTimeout()
+	}
+}
+
+// 创建收到 SIGINT/SIGTERM 时取消的 context。
+// 第一次信号只取消 context，让进行中的任务收尾并输出部分结果；
+// 之后的信号恢复默认行为，直接终止进程。
+func interruptContext(parent context.Context) (context.Context, context.CancelFunc) {
+	ctx, cancel := context.WithCancel(parent)
+
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, os.Interrupt, syscall.SIGTERM)
+	go func() {
+		defer signal.Stop(signals)
+		select {
+		case sig := <-signals:
+			fmt.Fprintf(os.Stderr, "\n收到 %v 信号，等待进行中的任务完成 (再次中断将强制退出)...\n", sig)
+			cancel()
+		case <-ctx.Done():
+		}
+	}()
+
+	return ctx, func() {
+		signal.Stop(signals)
+		cancel()
+	}
+}
+
+// 命令被取消时输出原因并设置退出码，返回是否已取消
+func reportCancellation(ctx context.Context) bool {
+	switch ctx.Err() {
+	case nil:
+		return false
+	case context.DeadlineExceeded:
+		fmt.Fprintln(os.Stderr, "警告: 超过 --timeout 指定的时间，已停止")
+		exitCode = 1
+	default:
+		fmt.Fprintln(os.Stderr, "警告: 操作已中断，以上为部分结果")
+		exitCode = 130
+	}
+	return true
+}
@@ -28,11 +28,12 @@ func printHelp() {
     benchmark      运行性能测试
     color-test     测试颜色支持
     config         配置管理 (init|show)
-    hash           计算文件哈希 <文件> <类型:md5|sha256>
-    url            测试URL连接 <URL>
-    find           查找文件 <目录> <模式>
-    analyze        分析目录 <目录>
-    process        并发处理文件 <目录> <工作线程数>
+    hash           计算文件哈希 <文件> <类型:md5|sha1|sha256|sha512|sha3-256|blake2b|crc32|xxh64...>
+    url            测试URL连接 [-X 方法] [-H 请求头] [-d 数据] [--body] <URL>
+    find           查找文件 [-L] <目录> <模式>
+    analyze        分析目录 [--top N] [--by ext|dir|owner] [--tree|--code|--snapshot|--diff] <目录>
+    process        并发处理文件 [-L] <目录> --op hash|lines|gzip|convert-eol|exec:CMD
+    dupes          查找重复文件 [选项] <目录1> [目录2] ...
     cat            显示文件内容 <文件1> [文件2] ...
     grep           在文件中搜索文本 <模式> [文件/目录]
     interactive    交互模式
@@ -41,6 +42,10 @@ func printHelp() {
     -version       显示版本信息
     -help          显示帮助信息
 
+遍历选项 (find, analyze, process, grep -r):
+    -L, --follow   跟随符号链接
+    --strict       遇到无法读取的条目时立即停止 (默认跳过并在结束时汇总警告)
+
 示例:
     %s version
     %s info
@@ -48,15 +53,17 @@ func printHelp() {
     %s color-test
     %s config init
     %s hash example.txt md5
+    %s hash -r dist/ > SHA256SUMS
     %s url https://github.com
     %s find . ".go"
     %s analyze /tmp
-    %s process . 4
+    %s process . --op lines
+    %s dupes --keep newest --delete ~/cache
     %s cat file.txt
     %s grep "func main" .
     %s interactive
 
-`, name, name, name, name, name, name, name, name, name, name, name, name, name, name, name)
+`, name, name, name, name, name, name, name, name, name, name, name, name, name, name, name, name, name)
 }
 
 // 打印系统信息
@@ -2,63 +2,606 @@ package main
 
 import (
 	"fmt"
+	"os"
+	"strconv"
 	"strings"
 )
 
 // 哈希命令处理函数
 func handleHashCommand(args []string) {
-	if len(args) < 2 {
-		fmt.Println("用法: gast hash <文件> <类型:md5|sha256>")
+	options := &HashOptions{Algorithm: "sha256", Format: "gnu", Workers: configuredWorkers()}
+	options.Digest.Encoding = "hex"
+	algorithmSet := false
+	checkMode := false
+	checkOptions := &CheckOptions{Workers: options.Workers}
+	treeMode := false
+	treeOptions := &TreeHashOptions{}
+	var targets []string
+	
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+		if parseWalkOption(arg, &options.Walk) {
+			continue
+		}
+		
+		switch arg {
+		case "-r", "--recursive":
+			options.Recursive = true
+		case "-a", "--algorithm":
+			if i+1 >= len(args) {
+				fmt.Printf("错误: %s 选项需要指定哈希类型\n", arg)
+				return
+			}
+			i++
+			options.Algorithm = args[i]
+			algorithmSet = true
+		case "--format":
+			if i+1 >= len(args) {
+				fmt.Println("错误: --format 选项需要指定输出格式")
+				return
+			}
+			i++
+			options.Format = args[i]
+		case "--hmac-key-file":
+			if i+1 >= len(args) {
+				fmt.Println("错误: --hmac-key-file 选项需要指定密钥文件")
+				return
+			}
+			i++
+			key, err := os.ReadFile(args[i])
+			if err != nil {
+				fmt.Printf("读取密钥文件失败: %v\n", err)
+				return
+			}
+			if len(key) == 0 {
+				fmt.Println("错误: 密钥文件为空")
+				return
+			}
+			options.Digest.Key = key
+			checkOptions.Key = key
+		case "--encoding":
+			if i+1 >= len(args) {
+				fmt.Println("错误: --encoding 选项需要指定编码")
+				return
+			}
+			i++
+			if args[i] != "hex" && args[i] != "base64" && args[i] != "base32" {
+				fmt.Printf("无效的编码: %s (可用: hex, base64, base32)\n", args[i])
+				return
+			}
+			options.Digest.Encoding = args[i]
+		case "-c", "--check":
+			checkMode = true
+		case "--tree":
+			treeMode = true
+		case "--exclude":
+			if i+1 >= len(args) {
+				fmt.Println("错误: --exclude 选项需要指定模式")
+				return
+			}
+			i++
+			treeOptions.Exclude = append(treeOptions.Exclude, args[i])
+		case "--ignore-mtime":
+			treeOptions.IgnoreMtime = true
+		case "-v", "--verbose":
+			treeOptions.Verbose = true
+		case "-q", "--quiet":
+			checkOptions.Quiet = true
+		case "--ignore-missing":
+			checkOptions.IgnoreMissing = true
+		case "--tag":
+			options.Format = "bsd"
+		case "--json":
+			options.Format = "json"
+		default:
+			if strings.HasPrefix(arg, "-") {
+				fmt.Printf("未知选项: %s\n", arg)
+				return
+			}
+			targets = append(targets, arg)
+		}
+	}
+	
+	if len(targets) == 0 {
+		fmt.Println("用法: gast hash <文件> <类型[,类型...]>")
+		fmt.Println("      gast hash [选项] <文件或目录>...")
+		fmt.Println("选项:")
+		fmt.Println("  -a, --algorithm TYPE  哈希类型 (默认sha256)")
+		fmt.Println("  -r, --recursive       递归处理目录")
+		fmt.Println("  --format gnu|bsd|json 输出格式: sha256sum格式、BSD标签格式或JSON (默认gnu)")
+		fmt.Println("  --tag                 等同于 --format bsd")
+		fmt.Println("  --json                等同于 --format json")
+		fmt.Println("  -L, --follow          跟随符号链接")
+		fmt.Println("  --strict              遇到无法读取的条目时停止")
+		fmt.Println("  --hmac-key-file FILE  使用文件内容作为密钥计算HMAC (BLAKE2b使用原生密钥模式)")
+		fmt.Println("  --encoding ENC        摘要编码: hex, base64, base32 (默认hex)")
+		fmt.Println("  -c, --check           根据清单文件校验 (支持sha256sum/md5sum/BSD格式)")
+		fmt.Println("  --tree                计算整个目录的Merkle摘要")
+		fmt.Println("  --exclude PATTERN     目录摘要中排除匹配的文件名或相对路径 (可重复)")
+		fmt.Println("  --ignore-mtime        目录摘要不包含修改时间")
+		fmt.Println("  -v, --verbose         输出每个子目录的摘要")
+		fmt.Println("  -q, --quiet           校验时不显示成功的文件")
+		fmt.Println("  --ignore-missing      校验时忽略不存在的文件")
+		fmt.Printf("可用类型: %s\n", strings.Join(hashAlgorithmNames(), ", "))
+		fmt.Println("示例:")
+		fmt.Println("  gast hash file.iso sha256")
+		fmt.Println("  gast hash disk.img md5,sha256,sha512")
+		fmt.Println("  gast hash -r dist/ > SHA256SUMS")
+		fmt.Println("  gast hash -a md5 --tag *.tar.gz")
+		fmt.Println("  gast hash --check SHA256SUMS")
+		fmt.Println("  gast hash --hmac-key-file key.bin artifact.tar.gz sha256")
+		fmt.Println("  gast hash --tree --ignore-mtime --exclude '*.log' build/ sha256")
+		return
+	}
+	
+	if checkMode {
+		if options.Digest.Encoding != "hex" {
+			fmt.Println("错误: 校验模式只支持十六进制清单")
+			return
+		}
+		if algorithmSet {
+			if _, err := newHash(options.Algorithm); err != nil {
+				fmt.Println(err)
+				return
+			}
+			checkOptions.Algorithm = strings.ToLower(options.Algorithm)
+		}
+		if !checkChecksums(targets, checkOptions) {
+			exitCode = 1
+		}
 		return
 	}
 	
-	filename := args[0]
-	hashType := args[1]
+	if treeMode {
+		// gast hash --tree <目录> [类型]
+		if len(targets) > 2 {
+			fmt.Println("错误: --tree 只接受一个目录和可选的哈希类型")
+			return
+		}
+		if len(targets) == 2 {
+			options.Algorithm = targets[1]
+		}
+		if _, err := newKeyedHash(options.Algorithm, options.Digest.Key); err != nil {
+			fmt.Println(err)
+			return
+		}
+		treeOptions.Algorithm = strings.ToLower(options.Algorithm)
+		treeOptions.Digest = options.Digest
+		treeOptions.Walk = options.Walk
+		treeOptions.Workers = options.Workers
+		if !hashTree(targets[0], treeOptions) {
+			exitCode = 1
+		}
+		return
+	}
 	
-	hash, err := calculateFileHash(filename, hashType)
-	if err != nil {
-		fmt.Printf("计算哈希失败: %v\n", err)
+	if options.Format != "gnu" && options.Format != "bsd" && options.Format != "json" {
+		fmt.Printf("无效的输出格式: %s (可用: gnu, bsd, json)\n", options.Format)
+		return
+	}
+	if _, err := newKeyedHash(options.Algorithm, options.Digest.Key); err != nil {
+		fmt.Println(err)
 		return
 	}
 	
-	fmt.Printf("文件: %s\n", filename)
-	fmt.Printf("%s: %s\n", strings.ToUpper(hashType), hash)
+	// 单文件报告: gast hash <文件> <类型[,类型...]>，多个类型只读取一次文件
+	if len(targets) == 2 && !algorithmSet && !options.Recursive && options.Format == "gnu" {
+		if hashTypes, ok := parseHashTypes(targets[1]); ok {
+			if err := reportFileHashes(targets[0], hashTypes, &options.Digest); err != nil {
+				fmt.Printf("计算哈希失败: %v\n", err)
+				exitCode = 1
+			}
+			return
+		}
+	}
+	
+	if failed := hashFiles(targets, options); failed > 0 {
+		exitCode = 1
+	}
+}
+
+// 解析逗号分隔的哈希类型列表，包含未注册的类型时返回 false
+func parseHashTypes(list string) ([]string, bool) {
+	hashTypes := strings.Split(strings.ToLower(list), ",")
+	for _, hashType := range hashTypes {
+		if _, ok := hashAlgorithms[hashType]; !ok {
+			return nil, false
+		}
+	}
+	return hashTypes, true
+}
+
+// 分离遍历选项和位置参数
+func splitWalkArgs(args []string) (*WalkOptions, []string, error) {
+	options := &WalkOptions{}
+	var positional []string
+	
+	for _, arg := range args {
+		if parseWalkOption(arg, options) {
+			continue
+		}
+		if strings.HasPrefix(arg, "-") && len(arg) > 1 {
+			return nil, nil, fmt.Errorf("未知选项: %s", arg)
+		}
+		positional = append(positional, arg)
+	}
+	
+	return options, positional, nil
 }
 
 // 文件查找命令处理函数
 func handleFindCommand(args []string) {
-	if len(args) < 2 {
-		fmt.Println("用法: gast find <目录> <模式>")
+	walkOptions, positional, err := splitWalkArgs(args)
+	if err != nil {
+		fmt.Println(err)
 		return
 	}
 	
-	findFiles(args[0], args[1])
+	if len(positional) < 2 {
+		fmt.Println("用法: gast find [-L|--follow] [--strict] <目录> <模式>")
+		return
+	}
+	
+	findFiles(positional[0], positional[1], walkOptions)
 }
 
 // 目录分析命令处理函数
 func handleAnalyzeCommand(args []string) {
-	if len(args) < 1 {
-		fmt.Println("用法: gast analyze <目录>")
+	options := &AnalyzeOptions{Top: 10, By: "ext", MaxDepth: -1, Workers: configuredWorkers()}
+	var dirs []string
+	var snapshotFile string
+	var diffMode, withHash, codeMode, jsonOutput bool
+	
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+		if parseWalkOption(arg, &options.Walk) {
+			continue
+		}
+		
+		switch arg {
+		case "--top":
+			if i+1 >= len(args) {
+				fmt.Println("错误: --top 选项需要指定数量")
+				return
+			}
+			i++
+			top, err := strconv.Atoi(args[i])
+			if err != nil || top <= 0 {
+				fmt.Printf("错误: 无效的数量: %s\n", args[i])
+				return
+			}
+			options.Top = top
+		case "--by":
+			if i+1 >= len(args) {
+				fmt.Println("错误: --by 选项需要指定分组方式")
+				return
+			}
+			i++
+			if args[i] != "ext" && args[i] != "dir" && args[i] != "owner" {
+				fmt.Printf("无效的分组方式: %s (可用: ext, dir, owner)\n", args[i])
+				return
+			}
+			options.By = args[i]
+		case "--snapshot":
+			if i+1 >= len(args) {
+				fmt.Println("错误: --snapshot 选项需要指定输出文件")
+				return
+			}
+			i++
+			snapshotFile = args[i]
+		case "--hash":
+			withHash = true
+		case "--diff":
+			diffMode = true
+		case "--code":
+			codeMode = true
+		case "--json":
+			jsonOutput = true
+		case "--tree":
+			options.Tree = true
+		case "--apparent":
+			options.Apparent = true
+		case "--max-depth":
+			if i+1 >= len(args) {
+				fmt.Println("错误: --max-depth 选项需要指定深度")
+				return
+			}
+			i++
+			depth, err := strconv.Atoi(args[i])
+			if err != nil || depth < 0 {
+				fmt.Printf("错误: 无效的深度: %s\n", args[i])
+				return
+			}
+			options.MaxDepth = depth
+		default:
+			if strings.HasPrefix(arg, "-") {
+				fmt.Printf("未知选项: %s\n", arg)
+				return
+			}
+			dirs = append(dirs, arg)
+		}
+	}
+	
+	if len(dirs) < 1 {
+		fmt.Println("用法: gast analyze [选项] <目录>")
+		fmt.Println("选项:")
+		fmt.Println("  --top N             每个排行显示前N项 (默认10)")
+		fmt.Println("  --by ext|dir|owner  分组统计方式: 扩展名、一级子目录或所有者 (默认ext)")
+		fmt.Println("  --tree              显示按大小排序的目录占用树")
+		fmt.Println("  --max-depth N       占用树的最大显示深度")
+		fmt.Println("  --apparent          使用表观大小而不是磁盘占用")
+		fmt.Println("  --snapshot FILE     将文件路径、大小、修改时间和权限保存为JSON快照")
+		fmt.Println("  --hash              快照中同时记录SHA-256")
+		fmt.Println("  --diff A B          比较快照A与快照B或目录B")
+		fmt.Println("  --code              按语言统计文件数、空行、注释行和代码行")
+		fmt.Println("  --json              以JSON格式输出代码统计")
+		fmt.Println("  -L, --follow        跟随符号链接")
+		fmt.Println("  --strict            遇到无法读取的条目时停止")
+		fmt.Println("示例:")
+		fmt.Println("  gast analyze /var/log")
+		fmt.Println("  gast analyze --top 20 --by owner /home")
+		fmt.Println("  gast analyze --tree --max-depth 2 /var")
+		fmt.Println("  gast analyze --snapshot before.json --hash /srv/build")
+		fmt.Println("  gast analyze --diff before.json /srv/build")
+		fmt.Println("  gast analyze --code --json src/")
 		return
 	}
 	
-	analyzeDirectory(args[0])
+	if diffMode {
+		if len(dirs) < 2 {
+			fmt.Println("用法: gast analyze --diff <旧快照> <新快照|目录>")
+			return
+		}
+		diffSnapshots(dirs[0], dirs[1], options)
+		return
+	}
+	if codeMode {
+		countCode(dirs[0], options, jsonOutput)
+		return
+	}
+	if snapshotFile != "" {
+		snapshotDirectory(dirs[0], snapshotFile, withHash, options)
+		return
+	}
+	if options.Tree {
+		analyzeTree(dirs[0], options)
+		return
+	}
+	analyzeDirectory(dirs[0], options)
 }
 
 // 文件处理命令处理函数
 func handleProcessCommand(args []string) {
-	workers := 4
+	options := &ProcessOptions{Workers: configuredWorkers()}
 	dir := "."
+	var positional []string
+	
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+		if parseWalkOption(arg, &options.Walk) {
+			continue
+		}
+		
+		switch arg {
+		case "--op":
+			if i+1 >= len(args) {
+				fmt.Println("错误: --op 选项需要指定操作")
+				return
+			}
+			i++
+			options.Op = args[i]
+		case "-w", "--workers":
+			if i+1 >= len(args) {
+				fmt.Printf("错误: %s 选项需要指定工作线程数\n", arg)
+				return
+			}
+			i++
+			workers, err := strconv.Atoi(args[i])
+			if err != nil || workers < 1 {
+				fmt.Printf("无效的工作线程数: %s\n", args[i])
+				return
+			}
+			options.Workers = workers
+		case "-q", "--quiet":
+			options.Quiet = true
+		case "--journal":
+			if i+1 >= len(args) {
+				fmt.Println("错误: --journal 选项需要指定日志文件")
+				return
+			}
+			i++
+			options.Journal = args[i]
+		case "--max-rate", "--max-bandwidth":
+			if i+1 >= len(args) {
+				fmt.Printf("错误: %s 选项需要指定速率\n", arg)
+				return
+			}
+			i++
+			rate, err := strconv.ParseFloat(args[i], 64)
+			if err != nil || rate <= 0 {
+				fmt.Printf("无效的速率: %s\n", args[i])
+				return
+			}
+			if arg == "--max-rate" {
+				options.MaxRate = rate
+			} else {
+				options.MaxBandwidth = rate
+			}
+		case "--timeout":
+			if i+1 >= len(args) {
+				fmt.Println("错误: --timeout 选项需要指定时间")
+				return
+			}
+			i++
+			timeout, err := parseTimeout(args[i])
+			if err != nil {
+				fmt.Println(err)
+				return
+			}
+			options.Timeout = timeout
+		case "--resume":
+			options.Resume = true
+		case "--retry-failed":
+			options.RetryFailed = true
+		default:
+			if strings.HasPrefix(arg, "-") {
+				fmt.Printf("未知选项: %s\n", arg)
+				return
+			}
+			positional = append(positional, arg)
+		}
+	}
+	
+	if options.Op == "" {
+		fmt.Println("用法: gast process [选项] <目录> --op <操作> [工作线程数]")
+		fmt.Println("操作:")
+		for _, usage := range processorUsage {
+			fmt.Printf("  %s\n", usage)
+		}
+		fmt.Println("选项:")
+		fmt.Println("  -w, --workers N    工作线程数 (默认使用配置的 max_workers)")
+		fmt.Println("  -q, --quiet        只输出失败报告和汇总")
+		fmt.Println("  --max-rate N       每秒最多处理 N 个文件")
+		fmt.Println("  --max-bandwidth N  每秒最多处理 N MB (按文件大小计算)")
+		fmt.Println("  --journal FILE     把已处理的文件记录到日志，中断后可以继续")
+		fmt.Println("  --resume           跳过日志中已成功处理的文件，失败的文件会重新处理 (需要 --journal)")
+		fmt.Println("  --retry-failed     只重新处理日志中失败的文件 (需要 --journal)")
+		fmt.Println("  --timeout T        整体运行时间上限，如 2h 或秒数 (默认不限制)")
+		fmt.Println("  -L, --follow       跟随符号链接")
+		fmt.Println("  --strict           遇到无法读取的条目时停止")
+		fmt.Println("示例:")
+		fmt.Println("  gast process src --op lines")
+		fmt.Println("  gast process logs --op gzip -w 8")
+		fmt.Println("  gast process . --op convert-eol:lf")
+		fmt.Println("  gast process images --op 'exec:optipng -quiet {}'")
+		fmt.Println("  gast process logs --op 'exec:sh -c \"gzip -t \\\"$1\\\"\" _ {}'")
+		fmt.Println("  gast process /data --op gzip --journal gzip.journal --resume")
+		fmt.Println("  gast process /nfs/share --op hash --max-rate 50 --max-bandwidth 20")
+		fmt.Println("运行中发送 SIGUSR1 使限速减半，SIGUSR2 使限速加倍 (未设置限速时减速以当前实际速率为基准，加速不起作用)")
+		return
+	}
+	
+	if (options.Resume || options.RetryFailed) && options.Journal == "" {
+		fmt.Println("错误: --resume 和 --retry-failed 需要同时指定 --journal")
+		return
+	}
+	if options.Resume && options.RetryFailed {
+		fmt.Println("错误: --resume 和 --retry-failed 不能同时使用")
+		return
+	}
+	
+	processor, err := newProcessor(options.Op)
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
 	
-	if len(args) >= 1 {
-		dir = args[0]
+	if len(positional) >= 1 {
+		dir = positional[0]
+	}
+	if len(positional) >= 2 {
+		workers, err := strconv.Atoi(positional[1])
+		if err != nil || workers < 1 {
+			fmt.Printf("无效的工作线程数: %s\n", positional[1])
+			return
+		}
+		options.Workers = workers
 	}
 	
-	if len(args) >= 2 {
-		fmt.Sscanf(args[1], "%d", &workers)
+	ctx, cancel := commandContext(options.Timeout)
+	defer cancel()
+	processFiles(ctx, dir, processor, options)
+}
+
+// 重复文件查找命令处理函数
+func handleDupesCommand(args []string) {
+	options := &DupesOptions{MinSize: 1}
+	var dirs []string
+	
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+		if parseWalkOption(arg, &options.Walk) {
+			continue
+		}
+		
+		switch arg {
+		case "--json":
+			options.JSON = true
+		case "--delete":
+			options.Delete = true
+		case "--hardlink":
+			options.Hardlink = true
+		case "--dry-run":
+			options.DryRun = true
+		case "--keep", "--min-size":
+			if i+1 >= len(args) {
+				fmt.Printf("错误: %s 选项需要一个参数\n", arg)
+				return
+			}
+			i++
+			if arg == "--keep" {
+				options.Keep = args[i]
+				continue
+			}
+			minSize, err := strconv.ParseInt(args[i], 10, 64)
+			if err != nil || minSize < 0 {
+				fmt.Printf("错误: 无效的最小文件大小: %s\n", args[i])
+				return
+			}
+			options.MinSize = minSize
+		default:
+			if strings.HasPrefix(arg, "-") {
+				fmt.Printf("未知选项: %s\n", arg)
+				return
+			}
+			dirs = append(dirs, arg)
+		}
 	}
 	
-	processFiles(dir, workers)
+	if len(dirs) == 0 {
+		fmt.Println("用法: gast dupes [选项] <目录1> [目录2] ...")
+		fmt.Println("选项:")
+		fmt.Println("  --min-size N        忽略小于N字节的文件 (默认1，即忽略空文件)")
+		fmt.Println("  --json              以JSON格式输出")
+		fmt.Println("  --delete            删除重复文件，每组只保留一个 (未指定 --keep 时逐组询问)")
+		fmt.Println("  --hardlink          将重复文件替换为硬链接")
+		fmt.Println("  --keep RULE         保留规则: newest, oldest 或匹配路径的正则表达式")
+		fmt.Println("  --dry-run           只显示将要执行的操作")
+		fmt.Println("  -L, --follow        跟随符号链接")
+		fmt.Println("  --strict            遇到无法读取的条目时停止")
+		fmt.Println("示例:")
+		fmt.Println("  gast dupes ~/cache")
+		fmt.Println("  gast dupes --json build/ dist/")
+		fmt.Println("  gast dupes --delete --keep newest --dry-run ~/cache")
+		fmt.Println("  gast dupes --hardlink --keep '^/srv/cache/main/' /srv/cache")
+		return
+	}
+	
+	if options.Delete && options.Hardlink {
+		fmt.Println("错误: --delete 和 --hardlink 不能同时使用")
+		return
+	}
+	if options.JSON && (options.Delete || options.Hardlink) {
+		fmt.Println("错误: --json 不能与 --delete 或 --hardlink 同时使用")
+		return
+	}
+	
+	sets, stats, err := findDuplicates(dirs, options)
+	if err != nil {
+		fmt.Printf("查找重复文件失败: %v\n", err)
+		return
+	}
+	
+	printDuplicates(sets, options)
+	if options.JSON {
+		printWalkWarnings(stats)
+	} else {
+		printWalkStats(stats)
+	}
+	
+	if options.Delete || options.Hardlink {
+		reclaimDuplicates(sets, options)
+	}
 }
 
 // Cat命令处理函数
@@ -156,6 +699,9 @@ func handleFileCommands(subcommand string, args []string) bool {
 	case "process":
 		handleProcessCommand(args)
 		return true
+	case "dupes":
+		handleDupesCommand(args)
+		return true
 	case "cat":
 		handleCatCommand(args)
 		return true
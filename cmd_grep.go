@@ -14,12 +14,15 @@ func handleGrepCommand(args []string) {
 		fmt.Println("  -i, --ignore-case    忽略大小写")
 		fmt.Println("  -n, --line-number    显示行号")
 		fmt.Println("  -r, --recursive      递归搜索目录")
+		fmt.Println("  -L, --follow         递归搜索时跟随符号链接")
+		fmt.Println("  --strict             遇到无法读取的目录时停止搜索")
 		fmt.Println("  -v, --invert-match   反向匹配")
 		fmt.Println("  -c, --count          只显示匹配行数")
 		fmt.Println("  -l, --files-with-matches  只显示匹配的文件名")
 		fmt.Println("  -C, --context=NUM    显示匹配行前后各NUM行")
 		fmt.Println("  --color[=WHEN]       高亮匹配文本 (auto, always, never)")
 		fmt.Println("  --text               强制将二进制文件作为文本处理")
+		fmt.Println("  --timeout T          整体运行时间上限，如 5m 或秒数 (默认不限制)")
 		fmt.Println("示例:")
 		fmt.Println("  gast grep -i \"hello\" .")
 		fmt.Println("  gast grep -n \"func main\" main.go")
@@ -85,9 +88,23 @@ func handleGrepCommand(args []string) {
 				return
 			}
 			options.Context = contextNum
+		case "--timeout":
+			if i+1 >= len(args) {
+				fmt.Println("错误: --timeout 选项需要指定时间")
+				return
+			}
+			i++
+			timeout, err := parseTimeout(args[i])
+			if err != nil {
+				fmt.Println(err)
+				return
+			}
+			options.Timeout = timeout
 		default:
-			// 检查是否是--color=value格式
-			if strings.HasPrefix(arg, "--color=") {
+			// 检查是否是遍历选项或--color=value格式
+			if parseWalkOption(arg, &options.Walk) {
+				// 已处理
+			} else if strings.HasPrefix(arg, "--color=") {
 				colorValue := arg[8:] // 去掉"--color="
 				if colorValue == "auto" || colorValue == "always" || colorValue == "never" {
 					options.Color = colorValue
@@ -127,7 +144,9 @@ func handleGrepCommand(args []string) {
 		return
 	}
 	
-	grepSearch(pattern, targets, options)
+	ctx, cancel := commandContext(options.Timeout)
+	defer cancel()
+	grepSearch(ctx, pattern, targets, options)
 }
 
 // 处理grep相关命令
@@ -76,14 +76,16 @@ func interactiveMode() {
 					Text:        false,
 					Context:     0,
 				}
-				grepSearch(pattern, targets, options)
+				ctx, cancel := commandContext(0)
+				grepSearch(ctx, pattern, targets, options)
+				cancel()
 			}
 			continue
 		}
 		
 		// 未知命令
 		fmt.Printf("未知命令: %s\n", input)
-		fmt.Println("可用命令: info, version, config, benchmark, hash, url, find, analyze, process, cat, grep, quit")
+		fmt.Println("可用命令: info, version, config, benchmark, hash, url, find, analyze, process, dupes, cat, grep, quit")
 	}
 }
 
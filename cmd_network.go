@@ -1,17 +1,264 @@
 package main
 
 import (
+	"context"
 	"fmt"
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
 )
 
 // URL测试命令处理函数
 func handleURLCommand(args []string) {
-	if len(args) < 1 {
-		fmt.Println("用法: gast url <URL>")
+	options := &URLOptions{Headers: make(http.Header), Timeout: configuredTimeout()}
+	var targets []string
+	listFile := ""
+	crawlURL := ""
+	crawl := &CrawlOptions{Depth: 2}
+	
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+		switch arg {
+		case "-X", "--request":
+			if i+1 >= len(args) {
+				fmt.Printf("错误: %s 选项需要指定请求方法\n", arg)
+				return
+			}
+			i++
+			options.Method = args[i]
+		case "-H", "--header":
+			if i+1 >= len(args) {
+				fmt.Printf("错误: %s 选项需要指定请求头\n", arg)
+				return
+			}
+			i++
+			if err := parseHeader(args[i], options.Headers); err != nil {
+				fmt.Println(err)
+				return
+			}
+		case "-d", "--data":
+			if i+1 >= len(args) {
+				fmt.Printf("错误: %s 选项需要指定请求体\n", arg)
+				return
+			}
+			i++
+			options.Data = args[i]
+		case "--data-file":
+			if i+1 >= len(args) {
+				fmt.Println("错误: --data-file 选项需要指定文件")
+				return
+			}
+			i++
+			options.DataFile = args[i]
+		case "--timeout":
+			if i+1 >= len(args) {
+				fmt.Println("错误: --timeout 选项需要指定时间")
+				return
+			}
+			i++
+			timeout, err := parseTimeout(args[i])
+			if err != nil {
+				fmt.Println(err)
+				return
+			}
+			options.Timeout = timeout
+		case "-k", "--insecure":
+			options.Insecure = true
+		case "--no-redirect":
+			options.NoRedirect = true
+		case "--body":
+			options.ShowBody = true
+		case "--json":
+			options.JSON = true
+		case "--cert":
+			options.Cert = true
+		case "--warn-days":
+			if i+1 >= len(args) {
+				fmt.Println("错误: --warn-days 选项需要指定天数")
+				return
+			}
+			i++
+			days, err := strconv.Atoi(args[i])
+			if err != nil || days < 1 {
+				fmt.Printf("无效的天数: %s\n", args[i])
+				return
+			}
+			options.WarnDays = days
+			options.Cert = true
+		case "-f", "--file":
+			if i+1 >= len(args) {
+				fmt.Printf("错误: %s 选项需要指定URL列表文件\n", arg)
+				return
+			}
+			i++
+			listFile = args[i]
+		case "--crawl":
+			if i+1 >= len(args) {
+				fmt.Println("错误: --crawl 选项需要指定起始URL")
+				return
+			}
+			i++
+			crawlURL = args[i]
+		case "--depth":
+			if i+1 >= len(args) {
+				fmt.Println("错误: --depth 选项需要指定深度")
+				return
+			}
+			i++
+			depth, err := strconv.Atoi(args[i])
+			if err != nil || depth < 0 {
+				fmt.Printf("无效的深度: %s\n", args[i])
+				return
+			}
+			crawl.Depth = depth
+		case "--external":
+			crawl.External = true
+		case "--expect-status":
+			if i+1 >= len(args) {
+				fmt.Println("错误: --expect-status 选项需要指定状态码")
+				return
+			}
+			i++
+			codes, err := parseStatusList(args[i])
+			if err != nil {
+				fmt.Println(err)
+				return
+			}
+			options.ExpectStatus = codes
+		case "--expect-body":
+			if i+1 >= len(args) {
+				fmt.Println("错误: --expect-body 选项需要指定正则表达式")
+				return
+			}
+			i++
+			regex, err := regexp.Compile(args[i])
+			if err != nil {
+				fmt.Printf("正则表达式编译错误: %v\n", err)
+				return
+			}
+			options.ExpectBody = regex
+		case "--max-latency":
+			if i+1 >= len(args) {
+				fmt.Println("错误: --max-latency 选项需要指定时间")
+				return
+			}
+			i++
+			latency, err := time.ParseDuration(args[i])
+			if err != nil || latency <= 0 {
+				fmt.Printf("无效的延迟: %s\n", args[i])
+				return
+			}
+			options.MaxLatency = latency
+		case "-n", "--count", "-c", "--concurrency":
+			if i+1 >= len(args) {
+				fmt.Printf("错误: %s 选项需要指定数量\n", arg)
+				return
+			}
+			i++
+			n, err := strconv.Atoi(args[i])
+			if err != nil || n < 1 {
+				fmt.Printf("无效的数量: %s\n", args[i])
+				return
+			}
+			if arg == "-n" || arg == "--count" {
+				options.Count = n
+			} else {
+				options.Concurrency = n
+			}
+		default:
+			if strings.HasPrefix(arg, "-") {
+				fmt.Printf("未知选项: %s\n", arg)
+				return
+			}
+			targets = append(targets, arg)
+		}
+	}
+	
+	modes := len(targets)
+	if listFile != "" {
+		modes++
+	}
+	if crawlURL != "" {
+		modes++
+	}
+	if modes != 1 {
+		fmt.Println("用法: gast url [选项] <URL>")
+		fmt.Println("      gast url [选项] --file <URL列表>")
+		fmt.Println("      gast url [选项] --crawl <起始URL> [--depth N]")
+		fmt.Println("选项:")
+		fmt.Println("  -X, --request METHOD  请求方法 (默认GET，有请求体时为POST)")
+		fmt.Println("  -H, --header 'K: V'   添加请求头 (可重复)")
+		fmt.Println("  -d, --data DATA       请求体")
+		fmt.Println("  --data-file FILE      从文件读取请求体")
+		fmt.Println("  --timeout T           超时时间，如 10s、500ms 或秒数 (默认使用配置的 timeout，0 表示不限制)")
+		fmt.Println("  -k, --insecure        不校验TLS证书")
+		fmt.Println("  --no-redirect         不跟随重定向")
+		fmt.Println("  --body                输出响应体")
+		fmt.Println("  --json                以JSON输出状态、响应头和各阶段耗时")
+		fmt.Println("  -n, --count N         重复请求 N 次并统计延迟分布、状态码和错误率")
+		fmt.Println("  -c, --concurrency N   重复请求或批量检查时的并发数 (默认1，批量检查默认使用 max_workers)")
+		fmt.Println("  -f, --file FILE       并发检查文件中的URL (每行一个，# 开头为注释)")
+		fmt.Println("  --expect-status LIST  预期状态码，如 200 或 200,204 (默认4xx/5xx视为失败)")
+		fmt.Println("  --expect-body REGEX   响应体需要匹配的正则表达式")
+		fmt.Println("  --max-latency T       最大耗时，如 500ms")
+		fmt.Println("  --cert                输出HTTPS证书链 (主题、SAN、颁发者、有效期、密钥类型、是否可信)")
+		fmt.Println("  --warn-days N         证书在 N 天内过期时告警并以退出码1结束 (隐含 --cert)")
+		fmt.Println("  --crawl URL           从起始页爬取同一主机的页面，报告失效链接 (4xx/5xx、超时) 及其引用页")
+		fmt.Println("  --depth N             爬取的最大链接深度 (默认2，起始页为0)")
+		fmt.Println("  --external            爬取时同时检查其他主机的链接 (不继续爬取)")
+		fmt.Println("示例:")
+		fmt.Println("  gast url https://github.com")
+		fmt.Println("  gast url -X POST -H 'Content-Type: application/json' -d '{\"a\":1}' https://api.example.com/items")
+		fmt.Println("  gast url --no-redirect --timeout 5s http://example.com")
+		fmt.Println("  gast url --json https://example.com | jq .timing")
+		fmt.Println("  gast url --count 100 --concurrency 10 http://localhost:8080/health")
+		fmt.Println("  gast url --file urls.txt --expect-status 200 --max-latency 500ms")
+		fmt.Println("  gast url --cert --warn-days 30 https://example.com")
+		fmt.Println("  gast url --crawl https://docs.local --depth 3")
+		return
+	}
+	if options.Data != "" && options.DataFile != "" {
+		fmt.Println("错误: -d 和 --data-file 不能同时使用")
 		return
 	}
 	
-	testURL(args[0])
+	if crawlURL != "" {
+		crawl.Workers = options.Concurrency
+		if crawl.Workers < 1 {
+			crawl.Workers = configuredWorkers()
+		}
+		ctx, cancel := interruptContext(context.Background())
+		defer cancel()
+		crawlSite(ctx, crawlURL, options, crawl)
+		return
+	}
+	if listFile != "" {
+		ctx, cancel := interruptContext(context.Background())
+		defer cancel()
+		checkURLList(ctx, listFile, options)
+		return
+	}
+	if options.Count > 1 {
+		ctx, cancel := interruptContext(context.Background())
+		defer cancel()
+		probeURL(ctx, targets[0], options)
+		return
+	}
+	testURL(targets[0], options)
+}
+
+// 解析超时时间，支持 Go 时间格式或整数秒
+func parseTimeout(value string) (time.Duration, error) {
+	if seconds, err := strconv.Atoi(value); err == nil && seconds >= 0 {
+		return time.Duration(seconds) * time.Second, nil
+	}
+	timeout, err := time.ParseDuration(value)
+	if err != nil || timeout < 0 {
+		return 0, fmt.Errorf("无效的超时时间: %s", value)
+	}
+	return timeout, nil
 }
 
 // 处理网络相关命令
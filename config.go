@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"time"
 )
 
 type Config struct {
@@ -64,6 +65,27 @@ func saveConfig(config *Config) error {
 	return nil
 }
 
+// 获取配置的最大工作线程数，配置无效时使用默认值
+func configuredWorkers() int {
+	config, err := loadConfig()
+	if err != nil || config.MaxWorkers < 1 {
+		return 4
+	}
+	return config.MaxWorkers
+}
+
+// 获取配置的超时时间，0 或负数表示不限制
+func configuredTimeout() time.Duration {
+	config, err := loadConfig()
+	if err != nil {
+		return 30 * time.Second
+	}
+	if config.Timeout <= 0 {
+		return 0
+	}
+	return time.Duration(config.Timeout) * time.Second
+}
+
 func printConfig() {
 	config, err := loadConfig()
 	if err != nil {
@@ -0,0 +1,366 @@
+package main
+
+import (
+	"bufio"
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// 部分哈希读取的块大小（文件首尾各一块）
+const dupeBlockSize = 4096
+
+// 重复文件查找选项
+type DupesOptions struct {
+	Walk     WalkOptions
+	MinSize  int64  // 忽略小于该大小的文件
+	Keep     string // 保留规则: newest, oldest 或路径正则
+	Delete   bool   // 删除重复文件，仅保留一个
+	Hardlink bool   // 将重复文件替换为指向保留文件的硬链接
+	DryRun   bool   // 只显示将要执行的操作
+	JSON     bool   // JSON格式输出
+}
+
+// 待比较的文件
+type dupeFile struct {
+	Path string
+	Info os.FileInfo
+}
+
+// 一组内容相同的文件
+type DupeSet struct {
+	Size   int64    `json:"size"`
+	Hash   string   `json:"sha256"`
+	Files  []string `json:"files"`
+	Wasted int64    `json:"wasted"`
+
+	infos []os.FileInfo
+}
+
+// 查找重复文件：先按大小分组，再比较首尾块的部分哈希，最后比较完整SHA-256
+func findDuplicates(dirs []string, options *DupesOptions) ([]*DupeSet, *WalkStats, error) {
+	stats := &WalkStats{}
+	bySize := make(map[int64][]*dupeFile)
+
+	for _, dir := range dirs {
+		dirStats, err := walkTree(dir, &options.Walk, func(path string, info os.FileInfo) error {
+			if !info.Mode().IsRegular() || info.Size() < options.MinSize {
+				return nil
+			}
+			bySize[info.Size()] = append(bySize[info.Size()], &dupeFile{Path: path, Info: info})
+			return nil
+		})
+		mergeWalkStats(stats, dirStats)
+		if err != nil {
+			return nil, stats, err
+		}
+	}
+
+	var sets []*DupeSet
+	for size, files := range bySize {
+		files = dropHardlinked(files)
+		if len(files) < 2 {
+			continue
+		}
+
+		// 第二阶段：首尾块的部分哈希
+		byPartial := make(map[string][]*dupeFile)
+		for _, file := range files {
+			partial, err := partialFileHash(file.Path, size)
+			if err != nil {
+				stats.Errors = append(stats.Errors, WalkError{Path: file.Path, Err: err})
+				continue
+			}
+			byPartial[partial] = append(byPartial[partial], file)
+		}
+
+		for partial, candidates := range byPartial {
+			if len(candidates) < 2 {
+				continue
+			}
+
+			// 第三阶段：完整哈希。部分哈希已覆盖整个文件时无需再读
+			byHash := make(map[string][]*dupeFile)
+			for _, file := range candidates {
+				hash := partial
+				if size > 2*dupeBlockSize {
+					var err error
+					hash, err = calculateFileHash(file.Path, "sha256")
+					if err != nil {
+						stats.Errors = append(stats.Errors, WalkError{Path: file.Path, Err: err})
+						continue
+					}
+				}
+				byHash[hash] = append(byHash[hash], file)
+			}
+
+			for hash, same := range byHash {
+				if len(same) < 2 {
+					continue
+				}
+				set := &DupeSet{
+					Size:   size,
+					Hash:   hash,
+					Wasted: size * int64(len(same)-1),
+				}
+				sort.Slice(same, func(i, j int) bool { return same[i].Path < same[j].Path })
+				for _, file := range same {
+					set.Files = append(set.Files, file.Path)
+					set.infos = append(set.infos, file.Info)
+				}
+				sets = append(sets, set)
+			}
+		}
+	}
+
+	// 浪费空间最多的排在前面
+	sort.Slice(sets, func(i, j int) bool {
+		if sets[i].Wasted != sets[j].Wasted {
+			return sets[i].Wasted > sets[j].Wasted
+		}
+		return sets[i].Files[0] < sets[j].Files[0]
+	})
+
+	return sets, stats, nil
+}
+
+// 去掉已经互为硬链接的文件，它们并不占用额外空间
+func dropHardlinked(files []*dupeFile) []*dupeFile {
+	var unique []*dupeFile
+	for _, file := range files {
+		linked := false
+		for _, seen := range unique {
+			if os.SameFile(seen.Info, file.Info) {
+				linked = true
+				break
+			}
+		}
+		if !linked {
+			unique = append(unique, file)
+		}
+	}
+	return unique
+}
+
+// 计算文件首尾各一块的SHA-256，小文件直接计算全部内容
+func partialFileHash(filename string, size int64) (string, error) {
+	file, err := os.Open(filename)
+	if err != nil {
+		return "", err
+	}
+	defer file.Close()
+
+	hash := sha256.New()
+	if size <= 2*dupeBlockSize {
+		if _, err := io.Copy(hash, file); err != nil {
+			return "", err
+		}
+		return fmt.Sprintf("%x", hash.Sum(nil)), nil
+	}
+
+	if _, err := io.CopyN(hash, file, dupeBlockSize); err != nil {
+		return "", err
+	}
+	if _, err := file.Seek(-dupeBlockSize, io.SeekEnd); err != nil {
+		return "", err
+	}
+	if _, err := io.CopyN(hash, file, dupeBlockSize); err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("%x", hash.Sum(nil)), nil
+}
+
+// 合并多次遍历的统计
+func mergeWalkStats(dst *WalkStats, src *WalkStats) {
+	if src == nil {
+		return
+	}
+	dst.BrokenLinks = append(dst.BrokenLinks, src.BrokenLinks...)
+	dst.Loops = append(dst.Loops, src.Loops...)
+	dst.Errors = append(dst.Errors, src.Errors...)
+}
+
+// 根据保留规则选出要保留的文件下标，-1 表示没有文件符合规则
+func chooseKeeper(set *DupeSet, keep string) (int, error) {
+	switch keep {
+	case "", "first":
+		return 0, nil
+	case "newest", "oldest":
+		best := 0
+		for i, info := range set.infos {
+			bestTime := set.infos[best].ModTime()
+			if keep == "newest" && info.ModTime().After(bestTime) {
+				best = i
+			} else if keep == "oldest" && info.ModTime().Before(bestTime) {
+				best = i
+			}
+		}
+		return best, nil
+	default:
+		regex, err := regexp.Compile(keep)
+		if err != nil {
+			return -1, fmt.Errorf("无效的保留规则 %s: %v", keep, err)
+		}
+		for i, path := range set.Files {
+			if regex.MatchString(path) {
+				return i, nil
+			}
+		}
+		return -1, nil
+	}
+}
+
+// 交互式询问要保留的文件，返回 -1 表示跳过，quit 为 true 表示停止处理
+func askKeeper(set *DupeSet, reader *bufio.Reader) (index int, quit bool) {
+	for {
+		fmt.Printf("保留哪个文件? [1-%d, s=跳过, q=退出]: ", len(set.Files))
+		input, err := reader.ReadString('\n')
+		if err != nil && input == "" {
+			return -1, true
+		}
+
+		input = strings.TrimSpace(input)
+		switch input {
+		case "s":
+			return -1, false
+		case "q":
+			return -1, true
+		}
+
+		n, convErr := strconv.Atoi(input)
+		if convErr == nil && n >= 1 && n <= len(set.Files) {
+			return n - 1, false
+		}
+		fmt.Printf("无效输入: %s\n", input)
+	}
+}
+
+// 删除或硬链接重复文件，仅保留一个
+func reclaimDuplicates(sets []*DupeSet, options *DupesOptions) {
+	var reader *bufio.Reader
+	var reclaimed int64
+
+	for i, set := range sets {
+		var keeper int
+		if options.Delete && options.Keep == "" {
+			if reader == nil {
+				reader = bufio.NewReader(os.Stdin)
+			}
+			fmt.Printf("\n重复组 %d (%s):\n", i+1, formatSize(set.Size))
+			for j, path := range set.Files {
+				fmt.Printf("  [%d] %s (%s)\n", j+1, path, set.infos[j].ModTime().Format("2006-01-02 15:04:05"))
+			}
+			var quit bool
+			keeper, quit = askKeeper(set, reader)
+			if quit {
+				break
+			}
+			if keeper < 0 {
+				continue
+			}
+		} else {
+			var err error
+			keeper, err = chooseKeeper(set, options.Keep)
+			if err != nil {
+				fmt.Printf("错误: %v\n", err)
+				return
+			}
+			if keeper < 0 {
+				fmt.Printf("跳过重复组 %d: 没有文件匹配保留规则 %s\n", i+1, options.Keep)
+				continue
+			}
+		}
+
+		keepPath := set.Files[keeper]
+		for j, path := range set.Files {
+			if j == keeper {
+				continue
+			}
+
+			var err error
+			if options.Hardlink {
+				fmt.Printf("硬链接: %s -> %s\n", path, keepPath)
+				if !options.DryRun {
+					err = replaceWithHardlink(keepPath, path)
+				}
+			} else {
+				fmt.Printf("删除: %s (保留 %s)\n", path, keepPath)
+				if !options.DryRun {
+					err = os.Remove(path)
+				}
+			}
+
+			if err != nil {
+				fmt.Printf("  失败: %v\n", err)
+				continue
+			}
+			reclaimed += set.Size
+		}
+	}
+
+	if options.DryRun {
+		fmt.Printf("预计可回收空间: %s (未执行任何修改)\n", formatSize(reclaimed))
+	} else {
+		fmt.Printf("已回收空间: %s\n", formatSize(reclaimed))
+	}
+}
+
+// 用指向 target 的硬链接原子地替换 path
+func replaceWithHardlink(target string, path string) error {
+	tmp := filepath.Join(filepath.Dir(path), "."+filepath.Base(path)+".gast-link")
+	if err := os.Link(target, tmp); err != nil {
+		return err
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		os.Remove(tmp)
+		return err
+	}
+	return nil
+}
+
+// 打印重复文件报告
+func printDuplicates(sets []*DupeSet, options *DupesOptions) {
+	var totalWasted int64
+	var totalFiles int
+	for _, set := range sets {
+		totalWasted += set.Wasted
+		totalFiles += len(set.Files)
+	}
+
+	if options.JSON {
+		report := struct {
+			Sets        []*DupeSet `json:"sets"`
+			TotalWasted int64      `json:"total_wasted"`
+		}{
+			Sets:        sets,
+			TotalWasted: totalWasted,
+		}
+		if report.Sets == nil {
+			report.Sets = []*DupeSet{}
+		}
+		data, err := json.MarshalIndent(report, "", "  ")
+		if err != nil {
+			fmt.Printf("序列化结果失败: %v\n", err)
+			return
+		}
+		fmt.Println(string(data))
+		return
+	}
+
+	for i, set := range sets {
+		fmt.Printf("重复组 %d: %d 个文件, 每个 %s, 浪费 %s\n", i+1, len(set.Files), formatSize(set.Size), formatSize(set.Wasted))
+		fmt.Printf("  SHA256: %s\n", set.Hash)
+		for _, path := range set.Files {
+			fmt.Printf("  %s\n", path)
+		}
+	}
+
+	fmt.Printf("共 %d 组重复文件 (%d 个文件), 可回收空间: %s\n", len(sets), totalFiles, formatSize(totalWasted))
+}
@@ -0,0 +1,57 @@
+//go:build !windows
+
+package main
+
+import (
+	"os"
+	"os/user"
+	"strconv"
+	"sync"
+	"syscall"
+)
+
+var (
+	ownerNames   = make(map[uint32]string)
+	ownerNamesMu sync.Mutex
+)
+
+// 获取文件所有者的用户名，无法解析时返回UID
+func fileOwner(info os.FileInfo) string {
+	stat, ok := info.Sys().(*syscall.Stat_t)
+	if !ok {
+		return "unknown"
+	}
+
+	ownerNamesMu.Lock()
+	defer ownerNamesMu.Unlock()
+
+	if name, ok := ownerNames[stat.Uid]; ok {
+		return name
+	}
+
+	uid := strconv.FormatUint(uint64(stat.Uid), 10)
+	name := uid
+	if u, err := user.LookupId(uid); err == nil {
+		name = u.Username
+	}
+	ownerNames[stat.Uid] = name
+	return name
+}
+
+// 获取文件实际占用的磁盘空间（按512字节块计算）
+func fileDiskUsage(info os.FileInfo) int64 {
+	stat, ok := info.Sys().(*syscall.Stat_t)
+	if !ok {
+		return info.Size()
+	}
+	return int64(stat.Blocks) * 512
+}
+
+// 获取多链接文件的标识，只有一个链接的文件返回 false
+func hardlinkKey(info os.FileInfo) (fileKey, bool) {
+	stat, ok := info.Sys().(*syscall.Stat_t)
+	if !ok || info.IsDir() || stat.Nlink <= 1 {
+		return fileKey{}, false
+	}
+	return fileKey{Dev: uint64(stat.Dev), Ino: uint64(stat.Ino)}, true
+}
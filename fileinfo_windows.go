@@ -0,0 +1,22 @@
+//go:build windows
+
+package main
+
+import (
+	"os"
+)
+
+// Windows 上 FileInfo 不包含所有者信息
+func fileOwner(info os.FileInfo) string {
+	return "unknown"
+}
+
+// Windows 上无法直接获取块数，使用文件大小近似
+func fileDiskUsage(info os.FileInfo) int64 {
+	return info.Size()
+}
+
+// Windows 上不做硬链接识别
+func hardlinkKey(info os.FileInfo) (fileKey, bool) {
+	return fileKey{}, false
+}
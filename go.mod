@@ -1,3 +1,10 @@
 module gast
 
 go 1.18
+
+require (
+	github.com/cespare/xxhash/v2 v2.3.0
+	golang.org/x/crypto v0.24.0
+)
+
+require golang.org/x/sys v0.21.0 // indirect
@@ -0,0 +1,406 @@
+package main
+
+import (
+	"crypto/hmac"
+	"crypto/md5"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/base32"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"hash"
+	"hash/adler32"
+	"hash/crc32"
+	"hash/fnv"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/cespare/xxhash/v2"
+	"golang.org/x/crypto/blake2b"
+	"golang.org/x/crypto/sha3"
+)
+
+// 哈希算法注册表，新算法只需在此添加构造函数
+var hashAlgorithms = map[string]func() hash.Hash{
+	"md5":         md5.New,
+	"sha1":        sha1.New,
+	"sha224":      sha256.New224,
+	"sha256":      sha256.New,
+	"sha384":      sha512.New384,
+	"sha512":      sha512.New,
+	"sha3-256":    sha3.New256,
+	"sha3-512":    sha3.New512,
+	"blake2b":     newBlake2b512,
+	"blake2b-256": newBlake2b256,
+	"crc32":       func() hash.Hash { return crc32.NewIEEE() },
+	"crc32c":      func() hash.Hash { return crc32.New(crc32.MakeTable(crc32.Castagnoli)) },
+	"adler32":     func() hash.Hash { return adler32.New() },
+	"fnv64a":      func() hash.Hash { return fnv.New64a() },
+	"xxh64":       func() hash.Hash { return xxhash.New() },
+}
+
+// 无密钥的 BLAKE2b-512
+func newBlake2b512() hash.Hash {
+	h, _ := blake2b.New512(nil)
+	return h
+}
+
+// 无密钥的 BLAKE2b-256
+func newBlake2b256() hash.Hash {
+	h, _ := blake2b.New256(nil)
+	return h
+}
+
+// 原生支持密钥的算法，带密钥时不使用 HMAC 包装
+var keyedHashAlgorithms = map[string]func(key []byte) (hash.Hash, error){
+	"blake2b":     blake2b.New512,
+	"blake2b-256": blake2b.New256,
+}
+
+// 非密码学哈希，不能用于 HMAC
+var nonCryptoHashes = map[string]bool{
+	"crc32":   true,
+	"crc32c":  true,
+	"adler32": true,
+	"fnv64a":  true,
+	"xxh64":   true,
+}
+
+// 摘要参数
+type digestOptions struct {
+	Key      []byte // 非空时计算带密钥的摘要
+	Encoding string // 输出编码: hex, base64, base32
+}
+
+// 根据名称创建哈希实例
+func newHash(hashType string) (hash.Hash, error) {
+	return newKeyedHash(hashType, nil)
+}
+
+// 根据名称创建哈希实例，key 非空时返回 HMAC 或算法原生的密钥模式
+func newKeyedHash(hashType string, key []byte) (hash.Hash, error) {
+	hashType = strings.ToLower(hashType)
+	constructor, ok := hashAlgorithms[hashType]
+	if !ok {
+		return nil, fmt.Errorf("不支持的哈希类型: %s (可用: %s)", hashType, strings.Join(hashAlgorithmNames(), ", "))
+	}
+	if len(key) == 0 {
+		return constructor(), nil
+	}
+
+	if nonCryptoHashes[hashType] {
+		return nil, fmt.Errorf("%s 不是密码学哈希，不支持密钥", hashType)
+	}
+	if keyed, ok := keyedHashAlgorithms[hashType]; ok {
+		h, err := keyed(key)
+		if err != nil {
+			return nil, fmt.Errorf("%s 密钥无效: %v", hashType, err)
+		}
+		return h, nil
+	}
+	return hmac.New(constructor, key), nil
+}
+
+// 摘要的显示名称，例如 sha256 带密钥时为 hmac-sha256
+func digestName(hashType string, digest *digestOptions) string {
+	hashType = strings.ToLower(hashType)
+	if digest == nil || len(digest.Key) == 0 {
+		return hashType
+	}
+	if _, ok := keyedHashAlgorithms[hashType]; ok {
+		return "keyed-" + hashType
+	}
+	return "hmac-" + hashType
+}
+
+// 按指定编码输出摘要
+func encodeDigest(sum []byte, encoding string) string {
+	switch encoding {
+	case "base64":
+		return base64.StdEncoding.EncodeToString(sum)
+	case "base32":
+		return base32.StdEncoding.EncodeToString(sum)
+	default:
+		return fmt.Sprintf("%x", sum)
+	}
+}
+
+// 已注册的哈希算法名称
+func hashAlgorithmNames() []string {
+	names := make([]string, 0, len(hashAlgorithms))
+	for name := range hashAlgorithms {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// 文件哈希计算
+func calculateFileHash(filename string, hashType string) (string, error) {
+	digests, _, err := calculateFileHashes(filename, []string{hashType}, nil, nil)
+	if err != nil {
+		return "", err
+	}
+	return digests[0], nil
+}
+
+// 一次读取文件同时计算多个哈希，返回与 hashTypes 顺序一致的摘要和读取的字节数。
+// digest 为 nil 时输出无密钥的十六进制摘要；progress 不为 nil 时同时接收读取的数据用于显示进度
+func calculateFileHashes(filename string, hashTypes []string, digest *digestOptions, progress io.Writer) ([]string, int64, error) {
+	if digest == nil {
+		digest = &digestOptions{Encoding: "hex"}
+	}
+
+	hashes := make([]hash.Hash, len(hashTypes))
+	writers := make([]io.Writer, 0, len(hashTypes)+1)
+	for i, hashType := range hashTypes {
+		h, err := newKeyedHash(hashType, digest.Key)
+		if err != nil {
+			return nil, 0, err
+		}
+		hashes[i] = h
+		writers = append(writers, h)
+	}
+	if progress != nil {
+		writers = append(writers, progress)
+	}
+
+	file, err := os.Open(filename)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer file.Close()
+
+	n, err := io.Copy(io.MultiWriter(writers...), file)
+	if err != nil {
+		return nil, n, err
+	}
+
+	digests := make([]string, len(hashes))
+	for i, h := range hashes {
+		digests[i] = encodeDigest(h.Sum(nil), digest.Encoding)
+	}
+	return digests, n, nil
+}
+
+// 显示进度条的最小文件大小
+const hashProgressThreshold = 64 * 1024 * 1024
+
+// 计算单个文件的一个或多个哈希并输出报告，包括耗时和吞吐量
+func reportFileHashes(filename string, hashTypes []string, digest *digestOptions) error {
+	info, err := os.Stat(filename)
+	if err != nil {
+		return err
+	}
+
+	var progress *progressBar
+	if info.Size() >= hashProgressThreshold {
+		progress = newProgressBar(filepath.Base(filename), info.Size())
+	}
+
+	start := time.Now()
+	var progressWriter io.Writer
+	if progress != nil {
+		progressWriter = progress
+	}
+	digests, n, err := calculateFileHashes(filename, hashTypes, digest, progressWriter)
+	progress.Finish()
+	if err != nil {
+		return err
+	}
+	elapsed := time.Since(start)
+
+	fmt.Printf("文件: %s\n", filename)
+	for i, hashType := range hashTypes {
+		fmt.Printf("%s: %s\n", strings.ToUpper(digestName(hashType, digest)), digests[i])
+	}
+	if len(hashTypes) > 1 || info.Size() >= hashProgressThreshold {
+		throughput := 0.0
+		if elapsed > 0 {
+			throughput = float64(n) / 1024 / 1024 / elapsed.Seconds()
+		}
+		fmt.Printf("大小: %s, 耗时: %v, 吞吐量: %.2f MB/s\n", formatSize(n), elapsed.Round(time.Millisecond), throughput)
+	}
+	return nil
+}
+
+// 哈希命令选项
+type HashOptions struct {
+	Algorithm string // -a 哈希算法
+	Recursive bool   // -r 递归处理目录
+	Format    string // --format: gnu (sha256sum), bsd, json
+	Digest    digestOptions
+	Walk      WalkOptions
+	Workers   int
+}
+
+// 待计算哈希的文件
+type hashJob struct {
+	Path      string
+	Algorithm string
+}
+
+// 单个文件的哈希结果
+type FileHashResult struct {
+	Path      string `json:"path"`
+	Algorithm string `json:"algorithm"`
+	Hash      string `json:"hash,omitempty"`
+	Error     string `json:"error,omitempty"`
+
+	err error
+}
+
+// 展开命令行给出的文件和目录，目录仅在递归模式下遍历，同时返回文件总大小
+func collectHashTargets(targets []string, options *HashOptions) ([]string, int64, *WalkStats) {
+	stats := &WalkStats{}
+	var files []string
+	var totalBytes int64
+
+	for _, target := range targets {
+		info, err := os.Stat(target)
+		if err != nil {
+			stats.Errors = append(stats.Errors, WalkError{Path: target, Err: err})
+			continue
+		}
+
+		if !info.IsDir() {
+			files = append(files, target)
+			totalBytes += info.Size()
+			continue
+		}
+
+		if !options.Recursive {
+			fmt.Fprintf(os.Stderr, "跳过目录: %s (使用 -r 选项递归处理)\n", target)
+			continue
+		}
+
+		dirStats, err := walkTree(target, &options.Walk, func(path string, info os.FileInfo) error {
+			if info.Mode().IsRegular() {
+				files = append(files, path)
+				totalBytes += info.Size()
+			}
+			return nil
+		})
+		mergeWalkStats(stats, dirStats)
+		if err != nil {
+			stats.Errors = append(stats.Errors, WalkError{Path: target, Err: err})
+		}
+	}
+
+	return files, totalBytes, stats
+}
+
+// 使用 workers 个工作线程并发计算哈希，结果顺序与 jobs 一致。progress 可以为 nil
+func hashFilesConcurrently(jobs []hashJob, digest *digestOptions, workers int, progress *progressBar) []*FileHashResult {
+	if workers < 1 {
+		workers = 1
+	}
+
+	results := make([]*FileHashResult, len(jobs))
+	indexes := make(chan int)
+	var wg sync.WaitGroup
+
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for index := range indexes {
+				job := jobs[index]
+				result := &FileHashResult{Path: job.Path, Algorithm: digestName(job.Algorithm, digest)}
+				var progressWriter io.Writer
+				if progress != nil {
+					progressWriter = progress
+				}
+				digests, _, err := calculateFileHashes(job.Path, []string{job.Algorithm}, digest, progressWriter)
+				progress.AddFile(0)
+				if err != nil {
+					result.err = err
+					result.Error = err.Error()
+				} else {
+					result.Hash = digests[0]
+				}
+				results[index] = result
+			}
+		}()
+	}
+
+	for i := range jobs {
+		indexes <- i
+	}
+	close(indexes)
+	wg.Wait()
+
+	return results
+}
+
+// 计算多个文件的哈希并按指定格式输出，返回失败的文件数
+func hashFiles(targets []string, options *HashOptions) int {
+	files, totalBytes, stats := collectHashTargets(targets, options)
+
+	jobs := make([]hashJob, len(files))
+	for i, path := range files {
+		jobs[i] = hashJob{Path: path, Algorithm: options.Algorithm}
+	}
+	progress := newProgress("哈希", int64(len(files)), totalBytes)
+	results := hashFilesConcurrently(jobs, &options.Digest, options.Workers, progress)
+	progress.Finish()
+
+	failed := len(stats.Errors)
+	for _, result := range results {
+		if result.err != nil {
+			fmt.Fprintf(os.Stderr, "%s: %v\n", result.Path, result.err)
+			failed++
+		}
+	}
+
+	// JSON 输出中同样列出无法读取的路径，便于程序判断哪些文件没有结果
+	if options.Format == "json" {
+		for _, walkErr := range stats.Errors {
+			results = append(results, &FileHashResult{
+				Path:      walkErr.Path,
+				Algorithm: digestName(options.Algorithm, &options.Digest),
+				Error:     walkErr.Err.Error(),
+				err:       walkErr.Err,
+			})
+		}
+	}
+
+	printHashResults(results, options.Format)
+	printWalkWarnings(stats)
+	return failed
+}
+
+// 按 sha256sum、BSD 标签或 JSON 格式输出哈希结果
+func printHashResults(results []*FileHashResult, format string) {
+	if format == "json" {
+		// 失败的文件带有 error 字段，没有 hash 字段
+		if results == nil {
+			results = []*FileHashResult{}
+		}
+		data, err := json.MarshalIndent(results, "", "  ")
+		if err != nil {
+			fmt.Printf("序列化结果失败: %v\n", err)
+			return
+		}
+		fmt.Println(string(data))
+		return
+	}
+
+	for _, result := range results {
+		if result.err != nil {
+			continue
+		}
+		if format == "bsd" {
+			fmt.Printf("%s (%s) = %s\n", strings.ToUpper(result.Algorithm), result.Path, result.Hash)
+		} else {
+			fmt.Printf("%s  %s\n", result.Hash, result.Path)
+		}
+	}
+}
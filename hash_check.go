@@ -0,0 +1,158 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+)
+
+// 校验选项
+type CheckOptions struct {
+	Algorithm     string // 为空时根据BSD标签或摘要长度推断
+	Quiet         bool   // 不输出校验成功的文件
+	IgnoreMissing bool   // 忽略不存在的文件
+	Key           []byte // HMAC密钥，校验带密钥的清单
+	Workers       int
+}
+
+// 清单中的一条记录
+type checksumEntry struct {
+	Path      string
+	Algorithm string
+	Expected  string
+}
+
+var (
+	// BSD 标签格式: SHA256 (file) = hex
+	bsdChecksumLine = regexp.MustCompile(`^([A-Za-z0-9-]+) \((.*)\) = ([0-9a-fA-F]+)$`)
+	// sha256sum 格式: hex  file 或 hex *file
+	gnuChecksumLine = regexp.MustCompile(`^([0-9a-fA-F]+) [ *](.*)$`)
+)
+
+// 根据摘要长度推断 sha*sum/md5sum 清单使用的算法
+var digestLengthAlgorithms = map[int]string{
+	32:  "md5",
+	40:  "sha1",
+	56:  "sha224",
+	64:  "sha256",
+	96:  "sha384",
+	128: "sha512",
+}
+
+// 解析校验和清单，返回记录和格式错误的行数
+func parseChecksumFile(filename string, algorithm string) ([]checksumEntry, int, error) {
+	file, err := os.Open(filename)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer file.Close()
+
+	var entries []checksumEntry
+	malformed := 0
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		line := strings.TrimRight(scanner.Text(), "\r")
+		if strings.TrimSpace(line) == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		var entry checksumEntry
+		if match := bsdChecksumLine.FindStringSubmatch(line); match != nil {
+			// 带密钥的清单使用 HMAC-SHA256 或 KEYED-BLAKE2B 这样的标签
+			algorithm := strings.ToLower(match[1])
+			algorithm = strings.TrimPrefix(strings.TrimPrefix(algorithm, "hmac-"), "keyed-")
+			entry = checksumEntry{Path: match[2], Algorithm: algorithm, Expected: match[3]}
+		} else if match := gnuChecksumLine.FindStringSubmatch(line); match != nil {
+			entry = checksumEntry{Path: match[2], Algorithm: digestLengthAlgorithms[len(match[1])], Expected: match[1]}
+		} else {
+			malformed++
+			continue
+		}
+
+		if algorithm != "" {
+			entry.Algorithm = algorithm
+		}
+		if _, ok := hashAlgorithms[entry.Algorithm]; !ok {
+			malformed++
+			continue
+		}
+		entry.Expected = strings.ToLower(entry.Expected)
+		entries = append(entries, entry)
+	}
+
+	return entries, malformed, scanner.Err()
+}
+
+// 根据清单并发重新计算哈希并逐个报告，返回是否全部通过
+func checkChecksums(manifests []string, options *CheckOptions) bool {
+	ok := true
+
+	for _, manifest := range manifests {
+		entries, malformed, err := parseChecksumFile(manifest, options.Algorithm)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "读取清单失败 %s: %v\n", manifest, err)
+			ok = false
+			continue
+		}
+		if len(entries) == 0 {
+			fmt.Fprintf(os.Stderr, "%s: 没有找到格式正确的校验和行\n", manifest)
+			ok = false
+			continue
+		}
+
+		jobs := make([]hashJob, len(entries))
+		for i, entry := range entries {
+			jobs[i] = hashJob{Path: entry.Path, Algorithm: entry.Algorithm}
+		}
+		results := hashFilesConcurrently(jobs, &digestOptions{Key: options.Key, Encoding: "hex"}, options.Workers, nil)
+
+		failed, missing, unreadable, ignored := 0, 0, 0, 0
+		for i, result := range results {
+			entry := entries[i]
+			switch {
+			case result.err != nil && os.IsNotExist(result.err):
+				if options.IgnoreMissing {
+					ignored++
+					continue
+				}
+				missing++
+				fmt.Printf("%s: MISSING\n", entry.Path)
+			case result.err != nil:
+				unreadable++
+				fmt.Printf("%s: FAILED open or read\n", entry.Path)
+			case result.Hash != entry.Expected:
+				failed++
+				fmt.Printf("%s: FAILED\n", entry.Path)
+			default:
+				if !options.Quiet {
+					fmt.Printf("%s: OK\n", entry.Path)
+				}
+			}
+		}
+
+		if malformed > 0 {
+			fmt.Fprintf(os.Stderr, "警告: %s 中有 %d 行格式不正确\n", manifest, malformed)
+		}
+		// 与 sha256sum --ignore-missing 一致，所有文件都不存在时视为失败
+		if ignored == len(entries) {
+			fmt.Fprintf(os.Stderr, "%s: 没有校验任何文件\n", manifest)
+			ok = false
+		}
+		if missing > 0 {
+			fmt.Fprintf(os.Stderr, "警告: %d 个文件不存在\n", missing)
+		}
+		if unreadable > 0 {
+			fmt.Fprintf(os.Stderr, "警告: %d 个文件无法读取\n", unreadable)
+		}
+		if failed > 0 {
+			fmt.Fprintf(os.Stderr, "警告: %d 个校验和不匹配\n", failed)
+		}
+		if failed > 0 || missing > 0 || unreadable > 0 {
+			ok = false
+		}
+	}
+
+	return ok
+}
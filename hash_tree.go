@@ -0,0 +1,236 @@
+package main
+
+import (
+	"encoding/hex"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// 目录树哈希选项
+type TreeHashOptions struct {
+	Algorithm   string
+	Digest      digestOptions
+	Walk        WalkOptions
+	Exclude     []string // --exclude 排除的文件名或相对路径模式
+	IgnoreMtime bool     // --ignore-mtime 不把修改时间计入摘要
+	Verbose     bool     // -v 输出每个子目录的摘要
+	Workers     int
+}
+
+// 目录树中的一个条目
+type treeNode struct {
+	Name     string
+	Path     string
+	Rel      string // 相对于根目录，使用 / 分隔
+	Info     os.FileInfo
+	Children []*treeNode
+	Sum      []byte
+}
+
+// 计算目录树摘要并输出，返回是否成功
+func hashTree(root string, options *TreeHashOptions) bool {
+	rootNode, stats, err := buildHashTree(root, options)
+	if err != nil {
+		fmt.Printf("计算目录树哈希失败: %v\n", err)
+		printWalkWarnings(stats)
+		return false
+	}
+	// 缺少条目的摘要没有意义，不输出
+	if len(stats.Errors) > 0 {
+		printWalkWarnings(stats)
+		fmt.Fprintln(os.Stderr, "错误: 部分条目无法读取，无法得到完整的目录树摘要")
+		return false
+	}
+
+	if options.Verbose {
+		var dirs []*treeNode
+		collectTreeDirs(rootNode, &dirs)
+		sort.Slice(dirs, func(i, j int) bool { return dirs[i].Rel < dirs[j].Rel })
+		for _, dir := range dirs {
+			fmt.Printf("%s  %s/\n", encodeDigest(dir.Sum, options.Digest.Encoding), dir.Rel)
+		}
+		fmt.Println()
+	}
+
+	fmt.Printf("%s (%s) = %s\n", strings.ToUpper(digestName(options.Algorithm, &options.Digest)), root,
+		encodeDigest(rootNode.Sum, options.Digest.Encoding))
+	printWalkStats(stats)
+	return true
+}
+
+// 遍历目录并自底向上计算每个目录的摘要
+func buildHashTree(root string, options *TreeHashOptions) (*treeNode, *WalkStats, error) {
+	root = filepath.Clean(root)
+	dirs := make(map[string]*treeNode)
+	var rootNode *treeNode
+	var files []*treeNode
+
+	stats, err := walkTree(root, &options.Walk, func(path string, info os.FileInfo) error {
+		rel, err := filepath.Rel(root, path)
+		if err != nil {
+			return err
+		}
+		rel = filepath.ToSlash(rel)
+		node := &treeNode{Name: info.Name(), Path: path, Rel: rel, Info: info}
+
+		if rel == "." {
+			if !info.IsDir() {
+				return fmt.Errorf("%s 不是目录", root)
+			}
+			rootNode = node
+			dirs[rel] = node
+			return nil
+		}
+
+		if excludedFromTree(rel, options.Exclude) {
+			if info.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+
+		parent := dirs[snapshotDir(rel)]
+		parent.Children = append(parent.Children, node)
+
+		switch {
+		case info.IsDir():
+			dirs[rel] = node
+		case info.Mode()&os.ModeSymlink != 0:
+			if err := sumSymlinkNode(node, options); err != nil {
+				return err
+			}
+		case info.Mode().IsRegular():
+			files = append(files, node)
+		default:
+			// 设备、管道等特殊文件只记录类型和权限
+			node.Sum = []byte{}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, stats, err
+	}
+
+	// 断开的符号链接不会传给回调，单独按链接目标计入所在目录，
+	// 否则链接的增删和目标变化都不会影响摘要
+	for _, path := range stats.BrokenLinks {
+		rel, err := filepath.Rel(root, path)
+		if err != nil {
+			return nil, stats, err
+		}
+		rel = filepath.ToSlash(rel)
+		parent := dirs[snapshotDir(rel)]
+		if parent == nil || excludedFromTree(rel, options.Exclude) {
+			continue
+		}
+		info, err := os.Lstat(path)
+		if err != nil {
+			stats.Errors = append(stats.Errors, WalkError{Path: path, Err: err})
+			continue
+		}
+		node := &treeNode{Name: info.Name(), Path: path, Rel: rel, Info: info}
+		if err := sumSymlinkNode(node, options); err != nil {
+			return nil, stats, err
+		}
+		parent.Children = append(parent.Children, node)
+	}
+
+	jobs := make([]hashJob, len(files))
+	for i, file := range files {
+		jobs[i] = hashJob{Path: file.Path, Algorithm: options.Algorithm}
+	}
+	results := hashFilesConcurrently(jobs, &digestOptions{Key: options.Digest.Key, Encoding: "hex"}, options.Workers, nil)
+	for i, result := range results {
+		if result.err != nil {
+			stats.Errors = append(stats.Errors, WalkError{Path: result.Path, Err: result.err})
+			continue
+		}
+		files[i].Sum, _ = hex.DecodeString(result.Hash)
+	}
+
+	if err := sumTreeNode(rootNode, options); err != nil {
+		return nil, stats, err
+	}
+	return rootNode, stats, nil
+}
+
+// 符号链接的摘要为链接目标路径的哈希
+func sumSymlinkNode(node *treeNode, options *TreeHashOptions) error {
+	target, err := os.Readlink(node.Path)
+	if err != nil {
+		return err
+	}
+	h, err := newKeyedHash(options.Algorithm, options.Digest.Key)
+	if err != nil {
+		return err
+	}
+	h.Write([]byte(target))
+	node.Sum = h.Sum(nil)
+	return nil
+}
+
+// 检查相对路径或文件名是否匹配排除模式
+func excludedFromTree(rel string, patterns []string) bool {
+	name := filepath.Base(filepath.FromSlash(rel))
+	for _, pattern := range patterns {
+		if matched, _ := filepath.Match(pattern, name); matched {
+			return true
+		}
+		if matched, _ := filepath.Match(pattern, rel); matched {
+			return true
+		}
+	}
+	return false
+}
+
+// 计算目录摘要：按名称排序的子条目逐行写入类型、权限、修改时间、摘要和名称
+func sumTreeNode(node *treeNode, options *TreeHashOptions) error {
+	sort.Slice(node.Children, func(i, j int) bool { return node.Children[i].Name < node.Children[j].Name })
+
+	h, err := newKeyedHash(options.Algorithm, options.Digest.Key)
+	if err != nil {
+		return err
+	}
+	for _, child := range node.Children {
+		if child.Info.IsDir() {
+			if err := sumTreeNode(child, options); err != nil {
+				return err
+			}
+		}
+
+		mtime := int64(0)
+		if !options.IgnoreMtime {
+			mtime = child.Info.ModTime().UnixNano()
+		}
+		fmt.Fprintf(h, "%c %o %d %x %s\x00", treeEntryKind(child.Info), child.Info.Mode().Perm(), mtime, child.Sum, child.Name)
+	}
+	node.Sum = h.Sum(nil)
+	return nil
+}
+
+// 条目类型标记
+func treeEntryKind(info os.FileInfo) byte {
+	switch {
+	case info.IsDir():
+		return 'd'
+	case info.Mode()&os.ModeSymlink != 0:
+		return 'l'
+	case info.Mode().IsRegular():
+		return 'f'
+	default:
+		return 'o'
+	}
+}
+
+// 收集所有目录节点
+func collectTreeDirs(node *treeNode, dirs *[]*treeNode) {
+	*dirs = append(*dirs, node)
+	for _, child := range node.Children {
+		if child.Info.IsDir() {
+			collectTreeDirs(child, dirs)
+		}
+	}
+}
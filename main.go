@@ -14,6 +14,9 @@ const (
 var (
 	showVersion = flag.Bool("version", false, "显示版本信息")
 	showHelp    = flag.Bool("help", false, "显示帮助信息")
+	
+	// 命令执行结束后的退出码，由校验失败等情况设置
+	exitCode = 0
 )
 
 func main() {
@@ -35,6 +38,7 @@ func main() {
 	
 	// 路由到对应的命令处理器
 	routeCommand(subcommand, args)
+	os.Exit(exitCode)
 }
 
 // 命令路由器
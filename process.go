@@ -0,0 +1,548 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// 文件处理器，每种 --op 操作实现该接口。Process 可能被多个工作线程同时调用
+type Processor interface {
+	Name() string
+	// 处理单个文件，返回结果摘要和读取的字节数
+	Process(path string) (output string, bytes int64, err error)
+}
+
+// 处理命令选项
+type ProcessOptions struct {
+	Op      string // --op 操作
+	Workers int
+	Quiet   bool // -q 只输出失败和汇总
+	Walk    WalkOptions
+
+	Journal     string // --journal 检查点日志文件
+	Resume      bool   // --resume 跳过日志中已成功处理的文件，失败的文件会重新处理
+	RetryFailed bool   // --retry-failed 只重新处理日志中失败的文件
+
+	MaxRate      float64 // --max-rate 每秒最多处理的文件数，0 表示不限制
+	MaxBandwidth float64 // --max-bandwidth 每秒最多处理的 MB 数，0 表示不限制
+
+	Timeout time.Duration // --timeout 整体运行时间上限，0 表示不限制
+}
+
+// 单个文件的处理结果
+type ProcessResult struct {
+	Path     string
+	Output   string
+	Bytes    int64
+	Duration time.Duration
+	Err      error
+}
+
+// 可用操作的说明
+var processorUsage = []string{
+	"hash[:TYPE]         计算文件哈希 (默认sha256)",
+	"lines               统计行数",
+	"gzip                压缩为 FILE.gz (保留原文件)",
+	"convert-eol[:crlf]  转换文本文件换行符为LF或CRLF (默认lf)",
+	"exec:CMD            对每个文件执行命令 (不经过shell，支持引号和反斜杠转义)，单独的 {} 参数替换为文件路径，否则追加到参数末尾",
+}
+
+// 根据 --op 参数创建处理器
+func newProcessor(op string) (Processor, error) {
+	name, arg, hasArg := strings.Cut(op, ":")
+
+	switch name {
+	case "hash":
+		hashType := "sha256"
+		if hasArg {
+			hashType = strings.ToLower(arg)
+		}
+		if _, err := newHash(hashType); err != nil {
+			return nil, err
+		}
+		return &hashProcessor{hashType: hashType}, nil
+	case "lines":
+		return lineCountProcessor{}, nil
+	case "gzip":
+		return gzipProcessor{}, nil
+	case "convert-eol":
+		eol := "lf"
+		if hasArg {
+			eol = strings.ToLower(arg)
+		}
+		if eol != "lf" && eol != "crlf" {
+			return nil, fmt.Errorf("无效的换行符: %s (可用: lf, crlf)", arg)
+		}
+		return &eolProcessor{crlf: eol == "crlf"}, nil
+	case "exec":
+		fields, err := splitCommandLine(arg)
+		if err != nil {
+			return nil, err
+		}
+		if len(fields) == 0 {
+			return nil, fmt.Errorf("exec 操作需要指定命令，例如 exec:\"wc -c\"")
+		}
+		// 与 find -exec 相同，只替换单独的 {} 参数，避免把文件名拼接进 sh -c 等脚本中执行
+		for _, field := range fields {
+			if field != "{}" && strings.Contains(field, "{}") {
+				return nil, fmt.Errorf("{} 只能作为单独的参数: %s (在 sh -c 中请使用 \"$1\" 并把 {} 作为后续参数)", field)
+			}
+		}
+		return &execProcessor{args: fields}, nil
+	}
+
+	return nil, fmt.Errorf("未知操作: %s", op)
+}
+
+// 哈希处理器
+type hashProcessor struct {
+	hashType string
+}
+
+func (p *hashProcessor) Name() string { return "hash:" + p.hashType }
+
+func (p *hashProcessor) Process(path string) (string, int64, error) {
+	digests, n, err := calculateFileHashes(path, []string{p.hashType}, nil, nil)
+	if err != nil {
+		return "", n, err
+	}
+	return digests[0], n, nil
+}
+
+// 行数统计处理器
+type lineCountProcessor struct{}
+
+func (lineCountProcessor) Name() string { return "lines" }
+
+func (lineCountProcessor) Process(path string) (string, int64, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return "", 0, err
+	}
+	defer file.Close()
+
+	lines := 0
+	var n int64
+	reader := bufio.NewReader(file)
+	buffer := make([]byte, 32*1024)
+	lastByte := byte('\n')
+	for {
+		count, err := reader.Read(buffer)
+		if count > 0 {
+			lines += bytes.Count(buffer[:count], []byte{'\n'})
+			lastByte = buffer[count-1]
+			n += int64(count)
+		}
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return "", n, err
+		}
+	}
+	// 最后一行没有换行符时也计入
+	if lastByte != '\n' {
+		lines++
+	}
+	return fmt.Sprintf("%d 行", lines), n, nil
+}
+
+// gzip 压缩处理器
+type gzipProcessor struct{}
+
+func (gzipProcessor) Name() string { return "gzip" }
+
+func (gzipProcessor) Process(path string) (string, int64, error) {
+	if strings.HasSuffix(path, ".gz") {
+		return "已跳过 (已压缩)", 0, nil
+	}
+
+	src, err := os.Open(path)
+	if err != nil {
+		return "", 0, err
+	}
+	defer src.Close()
+
+	info, err := src.Stat()
+	if err != nil {
+		return "", 0, err
+	}
+
+	target := path + ".gz"
+	dst, err := os.OpenFile(target, os.O_WRONLY|os.O_CREATE|os.O_EXCL, info.Mode().Perm())
+	if err != nil {
+		if os.IsExist(err) {
+			return "", 0, fmt.Errorf("%s 已存在", target)
+		}
+		return "", 0, err
+	}
+
+	zw := gzip.NewWriter(dst)
+	zw.Name = filepath.Base(path)
+	zw.ModTime = info.ModTime()
+	n, err := io.Copy(zw, src)
+	if err == nil {
+		err = zw.Close()
+	}
+	if closeErr := dst.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		os.Remove(target)
+		return "", n, err
+	}
+
+	compressed, err := os.Stat(target)
+	if err != nil {
+		return "", n, err
+	}
+	return fmt.Sprintf("%s -> %s (%.1f%%)", formatSize(n), formatSize(compressed.Size()), percentOf(compressed.Size(), n)), n, nil
+}
+
+// 换行符转换处理器
+type eolProcessor struct {
+	crlf bool
+}
+
+func (p *eolProcessor) Name() string {
+	if p.crlf {
+		return "convert-eol:crlf"
+	}
+	return "convert-eol:lf"
+}
+
+func (p *eolProcessor) Process(path string) (string, int64, error) {
+	if !isTextContent(path) {
+		return "已跳过 (非文本文件)", 0, nil
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", 0, err
+	}
+	n := int64(len(data))
+
+	converted := bytes.ReplaceAll(data, []byte("\r\n"), []byte("\n"))
+	if p.crlf {
+		converted = bytes.ReplaceAll(converted, []byte("\n"), []byte("\r\n"))
+	}
+	if bytes.Equal(converted, data) {
+		return "无需转换", n, nil
+	}
+
+	if err := replaceFileContent(path, converted); err != nil {
+		return "", n, err
+	}
+	return fmt.Sprintf("已转换 %d 行", bytes.Count(converted, []byte("\n"))), n, nil
+}
+
+// 通过临时文件和重命名替换文件内容，保留原权限
+func replaceFileContent(path string, data []byte) error {
+	info, err := os.Stat(path)
+	if err != nil {
+		return err
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".tmp*")
+	if err != nil {
+		return err
+	}
+	tmpName := tmp.Name()
+
+	_, err = tmp.Write(data)
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Chmod(tmpName, info.Mode().Perm())
+	}
+	if err == nil {
+		err = os.Rename(tmpName, path)
+	}
+	if err != nil {
+		os.Remove(tmpName)
+	}
+	return err
+}
+
+// 按类似 shell 的规则拆分命令行：空白分隔参数，单引号内原样保留，
+// 双引号内只有 \" \\ \$ \` 会被转义，引号外的反斜杠转义下一个字符。
+// 不做变量展开和通配符匹配
+func splitCommandLine(line string) ([]string, error) {
+	var args []string
+	var current strings.Builder
+	inArg := false   // 当前参数是否已开始，"" 也是一个参数
+	escaped := false // 上一个字符是反斜杠
+	var quote rune   // 当前所在的引号
+	for _, r := range line {
+		switch {
+		case escaped:
+			if quote == '"' && !strings.ContainsRune("\"\\$`", r) {
+				current.WriteRune('\\')
+			}
+			current.WriteRune(r)
+			escaped = false
+		case quote == '\'':
+			if r == '\'' {
+				quote = 0
+			} else {
+				current.WriteRune(r)
+			}
+		case r == '\\':
+			escaped = true
+			inArg = true
+		case quote == '"':
+			if r == '"' {
+				quote = 0
+			} else {
+				current.WriteRune(r)
+			}
+		case r == '\'' || r == '"':
+			quote = r
+			inArg = true
+		case r == ' ' || r == '\t' || r == '\n':
+			if inArg {
+				args = append(args, current.String())
+				current.Reset()
+				inArg = false
+			}
+		default:
+			current.WriteRune(r)
+			inArg = true
+		}
+	}
+
+	if escaped {
+		return nil, fmt.Errorf("命令以反斜杠结尾: %s", line)
+	}
+	if quote != 0 {
+		return nil, fmt.Errorf("命令中的引号没有闭合: %s", line)
+	}
+	if inArg {
+		args = append(args, current.String())
+	}
+	return args, nil
+}
+
+// 外部命令处理器
+type execProcessor struct {
+	args []string
+}
+
+func (p *execProcessor) Name() string { return "exec:" + strings.Join(p.args, " ") }
+
+func (p *execProcessor) Process(path string) (string, int64, error) {
+	args := make([]string, 0, len(p.args)+1)
+	replaced := false
+	for _, arg := range p.args {
+		if arg == "{}" {
+			arg = path
+			replaced = true
+		}
+		args = append(args, arg)
+	}
+	if !replaced {
+		args = append(args, path)
+	}
+
+	output, err := exec.Command(args[0], args[1:]...).CombinedOutput()
+	summary := lastOutputLine(output)
+	if err != nil {
+		if summary != "" {
+			return "", 0, fmt.Errorf("%v: %s", err, summary)
+		}
+		return "", 0, err
+	}
+	return summary, 0, nil
+}
+
+// 命令输出的最后一个非空行
+func lastOutputLine(output []byte) string {
+	lines := strings.Split(strings.TrimSpace(string(output)), "\n")
+	return strings.TrimSpace(lines[len(lines)-1])
+}
+
+// 并发处理目录中的文件。ctx 取消后不再分发新文件，等待进行中的文件处理完后输出部分汇总
+func processFiles(ctx context.Context, dir string, processor Processor, options *ProcessOptions) {
+	workers := options.Workers
+	if workers < 1 {
+		workers = 1
+	}
+
+	var journal *processJournal
+	var states map[string]string
+	if options.Journal != "" {
+		var err error
+		states, err = loadJournal(options.Journal, processor.Name())
+		if err != nil {
+			fmt.Printf("读取日志失败: %v\n", err)
+			exitCode = 1
+			return
+		}
+		if len(states) > 0 && !options.Resume && !options.RetryFailed {
+			fmt.Printf("错误: 日志 %s 已有记录，使用 --resume 继续、--retry-failed 重试失败的文件，或删除该文件重新开始\n", options.Journal)
+			exitCode = 1
+			return
+		}
+		journal, err = openJournal(options.Journal, processor.Name())
+		if err != nil {
+			fmt.Printf("打开日志失败: %v\n", err)
+			exitCode = 1
+			return
+		}
+		defer func() {
+			if err := journal.Close(); err != nil {
+				fmt.Fprintf(os.Stderr, "警告: 写入日志失败: %v\n", err)
+			}
+		}()
+	}
+
+	// 先收集文件列表，避免处理过程中生成的文件（如 .gz）被再次处理
+	var files []string
+	sizes := make(map[string]int64)
+	var totalBytes int64
+	var walkStats *WalkStats
+	resumed := 0
+	if options.RetryFailed {
+		for _, path := range failedJournalPaths(states) {
+			files = append(files, path)
+			if info, err := os.Stat(path); err == nil {
+				sizes[path] = info.Size()
+				totalBytes += info.Size()
+			}
+		}
+		fmt.Printf("重试日志中 %d 个失败的文件\n", len(files))
+	} else {
+		var err error
+		walkStats, err = walkTreeContext(ctx, dir, &options.Walk, func(path string, info os.FileInfo) error {
+			if !info.Mode().IsRegular() {
+				return nil
+			}
+			// 只跳过已成功的文件，上次失败的文件会重新处理
+			if states[journalPath(path)] == journalDone {
+				resumed++
+				return nil
+			}
+			files = append(files, path)
+			sizes[path] = info.Size()
+			totalBytes += info.Size()
+			return nil
+		})
+		if err != nil {
+			if !reportCancellation(ctx) {
+				fmt.Printf("遍历目录失败: %v\n", err)
+				exitCode = 1
+			}
+			return
+		}
+		if resumed > 0 {
+			fmt.Printf("跳过日志中已成功处理的 %d 个文件\n", resumed)
+		}
+	}
+
+	fmt.Printf("使用 %d 个工作线程对 %d 个文件执行 %s...\n", workers, len(files), processor.Name())
+	// 运行中可以通过信号调整限速，因此没有指定限制时也创建限速器
+	limiter := newThrottle(options.MaxRate, options.MaxBandwidth)
+	if options.MaxRate > 0 || options.MaxBandwidth > 0 {
+		fmt.Printf("限速: %s\n", limiter)
+	}
+	stopWatching := limiter.watchSignals(ctx)
+	defer stopWatching()
+
+	start := time.Now()
+	progress := newProgress(processor.Name(), int64(len(files)), totalBytes)
+
+	filesChan := make(chan string)
+	resultsChan := make(chan *ProcessResult)
+	var wg sync.WaitGroup
+
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for path := range filesChan {
+				if err := limiter.Wait(ctx, sizes[path]); err != nil {
+					continue
+				}
+				fileStart := time.Now()
+				output, n, err := processor.Process(path)
+				resultsChan <- &ProcessResult{Path: path, Output: output, Bytes: n, Duration: time.Since(fileStart), Err: err}
+			}
+		}()
+	}
+
+	go func() {
+		defer func() {
+			close(filesChan)
+			wg.Wait()
+			close(resultsChan)
+		}()
+		for _, path := range files {
+			select {
+			case filesChan <- path:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	var failures []*ProcessResult
+	succeeded := 0
+	var readBytes int64
+	for result := range resultsChan {
+		// 进度按文件大小统计，exec 等不读取文件的操作也能估算剩余时间
+		progress.AddFile(sizes[result.Path])
+		journal.Record(result)
+		readBytes += result.Bytes
+		if result.Err != nil {
+			failures = append(failures, result)
+			continue
+		}
+		succeeded++
+		if !options.Quiet {
+			progress.Printf("  %s: %s\n", result.Path, result.Output)
+		}
+	}
+	progress.Finish()
+
+	skipped := len(files) - succeeded - len(failures)
+	printProcessSummary(succeeded, failures, skipped, readBytes, time.Since(start))
+	printWalkStats(walkStats)
+	if len(failures) > 0 {
+		exitCode = 1
+	}
+	reportCancellation(ctx)
+}
+
+// 打印处理汇总和失败报告
+func printProcessSummary(succeeded int, failures []*ProcessResult, skipped int, totalBytes int64, elapsed time.Duration) {
+	throughput := 0.0
+	if elapsed > 0 {
+		throughput = float64(totalBytes) / 1024 / 1024 / elapsed.Seconds()
+	}
+	fmt.Printf("处理完成: 成功 %d 个, 失败 %d 个, 读取 %s, 耗时 %v, 吞吐量 %.2f MB/s\n",
+		succeeded, len(failures), formatSize(totalBytes), elapsed.Round(time.Millisecond), throughput)
+	if skipped > 0 {
+		fmt.Printf("未处理: %d 个文件\n", skipped)
+	}
+
+	if len(failures) == 0 {
+		return
+	}
+	sort.Slice(failures, func(i, j int) bool { return failures[i].Path < failures[j].Path })
+	fmt.Printf("\n失败报告 (%d 个文件):\n", len(failures))
+	for _, failure := range failures {
+		fmt.Printf("  %s: %v\n", failure.Path, failure.Err)
+	}
+}
@@ -0,0 +1,166 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+)
+
+// 日志中的一条记录，每行一个 JSON 对象。
+// 每次运行开始时写入只包含 Op 的头记录，之后每处理完一个文件写入一条结果
+type journalEntry struct {
+	Op     string `json:"op,omitempty"`
+	Path   string `json:"path,omitempty"` // 绝对路径
+	Status string `json:"status,omitempty"`
+	Error  string `json:"error,omitempty"`
+}
+
+// 文件处理状态
+const (
+	journalDone   = "done"
+	journalFailed = "failed"
+)
+
+// 累积多少条记录或经过多长时间后同步到磁盘
+const (
+	journalSyncEntries  = 1000
+	journalSyncInterval = time.Second
+)
+
+// process 的检查点日志，只追加写入
+type processJournal struct {
+	file     *os.File
+	writer   *bufio.Writer
+	pending  int
+	lastSync time.Time
+	err      error // 第一次写入错误，之后不再写入
+}
+
+// 读取已有的日志，返回每个文件最后一次的处理状态。
+// 日志记录的操作与 op 不同时返回错误；进程被强制终止时最后一行可能不完整，会被忽略
+func loadJournal(path string, op string) (map[string]string, error) {
+	file, err := os.Open(path)
+	if os.IsNotExist(err) {
+		return map[string]string{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	states := make(map[string]string)
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		var entry journalEntry
+		if err := json.Unmarshal(scanner.Bytes(), &entry); err != nil {
+			continue
+		}
+		if entry.Op != "" && entry.Op != op {
+			return nil, fmt.Errorf("日志 %s 记录的操作为 %s，与当前操作 %s 不同", path, entry.Op, op)
+		}
+		if entry.Path != "" {
+			states[entry.Path] = entry.Status
+		}
+	}
+	return states, scanner.Err()
+}
+
+// 以追加方式打开日志并写入本次运行的头记录
+func openJournal(path string, op string) (*processJournal, error) {
+	file, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0644)
+	if err != nil {
+		return nil, err
+	}
+
+	j := &processJournal{file: file, writer: bufio.NewWriter(file), lastSync: time.Now()}
+	j.write(journalEntry{Op: op})
+	if err := j.sync(); err != nil {
+		file.Close()
+		return nil, err
+	}
+	return j, nil
+}
+
+// 记录一个文件的处理结果，定期同步到磁盘
+func (j *processJournal) Record(result *ProcessResult) {
+	if j == nil {
+		return
+	}
+
+	entry := journalEntry{Path: journalPath(result.Path), Status: journalDone}
+	if result.Err != nil {
+		entry.Status = journalFailed
+		entry.Error = result.Err.Error()
+	}
+	j.write(entry)
+
+	j.pending++
+	if j.pending >= journalSyncEntries || time.Since(j.lastSync) >= journalSyncInterval {
+		j.sync()
+	}
+}
+
+// 同步并关闭日志，返回写入过程中的第一个错误
+func (j *processJournal) Close() error {
+	if j == nil {
+		return nil
+	}
+	j.sync()
+	if err := j.file.Close(); err != nil && j.err == nil {
+		j.err = err
+	}
+	return j.err
+}
+
+func (j *processJournal) write(entry journalEntry) {
+	if j.err != nil {
+		return
+	}
+	data, err := json.Marshal(entry)
+	if err != nil {
+		j.err = err
+		return
+	}
+	data = append(data, '\n')
+	if _, err := j.writer.Write(data); err != nil {
+		j.err = err
+	}
+}
+
+func (j *processJournal) sync() error {
+	if j.err == nil {
+		if err := j.writer.Flush(); err != nil {
+			j.err = err
+		} else if err := j.file.Sync(); err != nil {
+			j.err = err
+		}
+	}
+	j.pending = 0
+	j.lastSync = time.Now()
+	return j.err
+}
+
+// 日志中使用绝对路径，从其他工作目录恢复时也能匹配
+func journalPath(path string) string {
+	if abs, err := filepath.Abs(path); err == nil {
+		return abs
+	}
+	return path
+}
+
+// 日志中上次处理失败的文件，按路径排序
+func failedJournalPaths(states map[string]string) []string {
+	var paths []string
+	for path, status := range states {
+		if status == journalFailed {
+			paths = append(paths, path)
+		}
+	}
+	sort.Strings(paths)
+	return paths
+}
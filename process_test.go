@@ -0,0 +1,56 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestSplitCommandLine(t *testing.T) {
+	tests := []struct {
+		line string
+		want []string
+	}{
+		{"wc -c", []string{"wc", "-c"}},
+		{"  optipng   -quiet\t{} ", []string{"optipng", "-quiet", "{}"}},
+		{`sh -c "gzip -t \"\$1\"" _ {}`, []string{"sh", "-c", `gzip -t "$1"`, "_", "{}"}},
+		{`sh -c 'echo "$1"' _ {}`, []string{"sh", "-c", `echo "$1"`, "_", "{}"}},
+		{`printf "a \"b\" \x \\" ''`, []string{"printf", `a "b" \x \`, ""}},
+		{`cp {} backup\ dir/`, []string{"cp", "{}", "backup dir/"}},
+		{`a"b c"'d'`, []string{"ab cd"}},
+		{"", nil},
+	}
+
+	for _, tt := range tests {
+		got, err := splitCommandLine(tt.line)
+		if err != nil {
+			t.Errorf("splitCommandLine(%q) 返回错误: %v", tt.line, err)
+			continue
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("splitCommandLine(%q) = %q, 期望 %q", tt.line, got, tt.want)
+		}
+	}
+
+	for _, line := range []string{`sh -c "echo`, `echo 'a`, `echo a\`} {
+		if _, err := splitCommandLine(line); err == nil {
+			t.Errorf("splitCommandLine(%q) 应返回错误", line)
+		}
+	}
+}
+
+func TestNewExecProcessor(t *testing.T) {
+	for _, op := range []string{`exec:sh -c "gzip -t {}"`, `exec:cp {} {}.bak`} {
+		if _, err := newProcessor(op); err == nil {
+			t.Errorf("newProcessor(%q) 应拒绝嵌入在参数中的 {}", op)
+		}
+	}
+
+	processor, err := newProcessor(`exec:sh -c 'gzip -t "$1"' _ {}`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{"sh", "-c", `gzip -t "$1"`, "_", "{}"}
+	if args := processor.(*execProcessor).args; !reflect.DeepEqual(args, want) {
+		t.Errorf("args = %q, 期望 %q", args, want)
+	}
+}
@@ -0,0 +1,150 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// 进度条宽度
+const progressBarWidth = 30
+
+// 按文件数和字节数统计的进度，在标准错误上绘制单行进度条。
+// 总量为 0 时只显示计数和速率
+type progressBar struct {
+	label      string
+	totalFiles int64
+	totalBytes int64
+	files      int64
+	bytes      int64
+	start      time.Time
+	mu         sync.Mutex // 保证进度行和 Printf 的输出不交错
+	done       chan struct{}
+	finished   chan struct{}
+}
+
+// 创建按字节计数的进度条
+func newProgressBar(label string, total int64) *progressBar {
+	return newProgress(label, 0, total)
+}
+
+// 创建并启动进度条。标准输出或标准错误不是终端时返回 nil，nil 进度条的方法均为空操作
+func newProgress(label string, totalFiles int64, totalBytes int64) *progressBar {
+	if !isTerminal(os.Stdout) || !isTerminal(os.Stderr) {
+		return nil
+	}
+
+	p := &progressBar{
+		label:      label,
+		totalFiles: totalFiles,
+		totalBytes: totalBytes,
+		start:      time.Now(),
+		done:       make(chan struct{}),
+		finished:   make(chan struct{}),
+	}
+
+	go func() {
+		defer close(p.finished)
+		ticker := time.NewTicker(200 * time.Millisecond)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				p.render()
+			case <-p.done:
+				p.mu.Lock()
+				fmt.Fprint(os.Stderr, "\r\033[K")
+				p.mu.Unlock()
+				return
+			}
+		}
+	}()
+
+	return p
+}
+
+// 实现 io.Writer，用于统计经过的字节数
+func (p *progressBar) Write(b []byte) (int, error) {
+	if p != nil {
+		atomic.AddInt64(&p.bytes, int64(len(b)))
+	}
+	return len(b), nil
+}
+
+// 记录完成一个文件，bytes 为尚未通过 Write 统计的字节数
+func (p *progressBar) AddFile(bytes int64) {
+	if p == nil {
+		return
+	}
+	atomic.AddInt64(&p.files, 1)
+	atomic.AddInt64(&p.bytes, bytes)
+}
+
+// 向标准输出打印一行，先清除当前的进度行
+func (p *progressBar) Printf(format string, args ...interface{}) {
+	if p == nil {
+		fmt.Printf(format, args...)
+		return
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	fmt.Fprint(os.Stderr, "\r\033[K")
+	fmt.Printf(format, args...)
+}
+
+// 停止进度条并清除当前行
+func (p *progressBar) Finish() {
+	if p == nil {
+		return
+	}
+	close(p.done)
+	<-p.finished
+}
+
+// 绘制一行进度
+func (p *progressBar) render() {
+	files := atomic.LoadInt64(&p.files)
+	bytes := atomic.LoadInt64(&p.bytes)
+	elapsed := time.Since(p.start).Seconds()
+
+	rate := 0.0
+	if elapsed > 0 {
+		rate = float64(bytes) / elapsed
+	}
+
+	var line string
+	if p.totalFiles == 0 && p.totalBytes == 0 {
+		line = fmt.Sprintf("%s %d 个文件 %s %s/s", p.label, files, formatSize(bytes), formatSize(int64(rate)))
+	} else {
+		// 有字节总量时按字节计算进度，否则按文件数
+		current, total := bytes, p.totalBytes
+		if total == 0 {
+			current, total = files, p.totalFiles
+		}
+		percent := percentOf(current, total)
+		if percent > 100 {
+			percent = 100
+		}
+		filled := int(percent / 100 * progressBarWidth)
+		bar := strings.Repeat("█", filled) + strings.Repeat("░", progressBarWidth-filled)
+
+		eta := "--"
+		if elapsed > 0 && current > 0 && total > current {
+			remaining := float64(total-current) / (float64(current) / elapsed)
+			eta = time.Duration(remaining * float64(time.Second)).Round(time.Second).String()
+		}
+
+		line = fmt.Sprintf("%s [%s] %5.1f%%", p.label, bar, percent)
+		if p.totalFiles > 0 {
+			line += fmt.Sprintf(" %d/%d 个文件", files, p.totalFiles)
+		}
+		line += fmt.Sprintf(" %s/s ETA %s", formatSize(int64(rate)), eta)
+	}
+
+	p.mu.Lock()
+	fmt.Fprintf(os.Stderr, "\r\033[K%s", line)
+	p.mu.Unlock()
+}
@@ -0,0 +1,188 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+	"sync"
+	"time"
+)
+
+// 令牌桶，最多积累一秒的令牌。令牌不足时允许透支，由调用方等待透支的时间，
+// 因此单个大文件也能通过带宽限制
+type tokenBucket struct {
+	mu     sync.Mutex
+	rate   float64 // 每秒令牌数，0 表示不限制
+	tokens float64
+	last   time.Time
+	used   float64 // 累计消耗的令牌，用于计算实际速率
+	start  time.Time
+}
+
+func newTokenBucket(rate float64) *tokenBucket {
+	now := time.Now()
+	return &tokenBucket{rate: rate, last: now, start: now}
+}
+
+// 取出 n 个令牌，返回需要等待的时间
+func (b *tokenBucket) reserve(n float64) time.Duration {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.used += n
+	if b.rate <= 0 {
+		return 0
+	}
+	b.refill()
+	b.tokens -= n
+	if b.tokens >= 0 {
+		return 0
+	}
+	return time.Duration(-b.tokens / b.rate * float64(time.Second))
+}
+
+// 按经过的时间补充令牌，调用方需持有锁
+func (b *tokenBucket) refill() {
+	now := time.Now()
+	if b.rate > 0 {
+		b.tokens += now.Sub(b.last).Seconds() * b.rate
+		if b.tokens > b.rate {
+			b.tokens = b.rate
+		}
+	}
+	b.last = now
+}
+
+// 修改速率
+func (b *tokenBucket) setRate(rate float64) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.refill()
+	b.rate = rate
+	if b.tokens > rate {
+		b.tokens = rate
+	}
+}
+
+// 当前速率限制和启动以来的实际速率
+func (b *tokenBucket) rates() (limit float64, observed float64) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	elapsed := time.Since(b.start).Seconds()
+	if elapsed > 0 {
+		observed = b.used / elapsed
+	}
+	return b.rate, observed
+}
+
+// 工作线程的限速器，分别限制每秒文件数和每秒字节数
+type throttle struct {
+	files *tokenBucket
+	bytes *tokenBucket
+}
+
+// 创建限速器，maxRate 为每秒文件数，maxBandwidth 为每秒 MB 数，0 表示不限制
+func newThrottle(maxRate float64, maxBandwidth float64) *throttle {
+	return &throttle{
+		files: newTokenBucket(maxRate),
+		bytes: newTokenBucket(maxBandwidth * 1024 * 1024),
+	}
+}
+
+// 在处理大小为 size 的文件前等待，ctx 取消时返回错误
+func (t *throttle) Wait(ctx context.Context, size int64) error {
+	delay := t.files.reserve(1)
+	if d := t.bytes.reserve(float64(size)); d > delay {
+		delay = d
+	}
+	if delay <= 0 {
+		return ctx.Err()
+	}
+
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// 按比例调整已设置的限速，返回是否有限速被修改。都未设置时减速以当前的实际速率为基准
+// 同时限制文件数和带宽，加速则不做任何事，避免给不限速的任务加上限制
+func (t *throttle) adjust(factor float64) bool {
+	fileLimit, _ := t.files.rates()
+	byteLimit, _ := t.bytes.rates()
+	unlimited := fileLimit <= 0 && byteLimit <= 0
+	if unlimited && factor >= 1 {
+		return false
+	}
+
+	changed := false
+	for _, b := range []*tokenBucket{t.files, t.bytes} {
+		limit, observed := b.rates()
+		if limit <= 0 && unlimited {
+			limit = observed
+		}
+		if limit <= 0 {
+			continue
+		}
+		b.setRate(limit * factor)
+		changed = true
+	}
+	return changed
+}
+
+// 当前限速的描述
+func (t *throttle) String() string {
+	describe := func(limit float64, format func(float64) string) string {
+		if limit <= 0 {
+			return "不限"
+		}
+		return format(limit)
+	}
+	fileLimit, _ := t.files.rates()
+	byteLimit, _ := t.bytes.rates()
+	return fmt.Sprintf("文件 %s, 带宽 %s",
+		describe(fileLimit, func(v float64) string { return fmt.Sprintf("%.1f 个/s", v) }),
+		describe(byteLimit, func(v float64) string { return formatSize(int64(v)) + "/s" }))
+}
+
+// 监听调整限速的信号 (throttle_unix.go)：减速信号使速率减半，加速信号使速率加倍。
+// 返回停止监听的函数
+func (t *throttle) watchSignals(ctx context.Context) func() {
+	if throttleSlowerSignal == nil {
+		return func() {}
+	}
+
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, throttleSlowerSignal, throttleFasterSignal)
+	done := make(chan struct{})
+	go func() {
+		for {
+			select {
+			case sig := <-signals:
+				factor := 2.0
+				if sig == throttleSlowerSignal {
+					factor = 0.5
+				}
+				if t.adjust(factor) {
+					fmt.Fprintf(os.Stderr, "\r\033[K限速已调整: %s\n", t)
+				} else {
+					fmt.Fprintf(os.Stderr, "\r\033[K未设置限速，忽略加速信号\n")
+				}
+			case <-ctx.Done():
+				return
+			case <-done:
+				return
+			}
+		}
+	}()
+
+	return func() {
+		signal.Stop(signals)
+		close(done)
+	}
+}
@@ -0,0 +1,14 @@
+//go:build !windows
+
+package main
+
+import (
+	"os"
+	"syscall"
+)
+
+// SIGUSR1 使限速减半，SIGUSR2 使限速加倍
+var (
+	throttleSlowerSignal os.Signal = syscall.SIGUSR1
+	throttleFasterSignal os.Signal = syscall.SIGUSR2
+)
@@ -0,0 +1,13 @@
+//go:build windows
+
+package main
+
+import (
+	"os"
+)
+
+// Windows 没有 SIGUSR1/SIGUSR2，运行中不能调整限速
+var (
+	throttleSlowerSignal os.Signal
+	throttleFasterSignal os.Signal
+)
@@ -0,0 +1,190 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// 读取URL列表文件，忽略空行和 # 注释
+func readURLList(filename string) ([]string, error) {
+	file, err := os.Open(filename)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	var urls []string
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		urls = append(urls, line)
+	}
+	return urls, scanner.Err()
+}
+
+// 解析逗号分隔的状态码列表
+func parseStatusList(list string) ([]int, error) {
+	var codes []int
+	for _, field := range strings.Split(list, ",") {
+		code, err := strconv.Atoi(strings.TrimSpace(field))
+		if err != nil || code < 100 || code > 999 {
+			return nil, fmt.Errorf("无效的状态码: %s", field)
+		}
+		codes = append(codes, code)
+	}
+	return codes, nil
+}
+
+// 检查结果是否满足预期，返回不满足的原因。
+// 没有指定 --expect-status 时，4xx/5xx 状态码视为失败
+func checkExpectations(result *URLResult, options *URLOptions) []string {
+	if result.err != nil {
+		return []string{probeErrorKind(result.err)}
+	}
+
+	var failures []string
+	if len(options.ExpectStatus) > 0 {
+		matched := false
+		for _, code := range options.ExpectStatus {
+			if result.StatusCode == code {
+				matched = true
+				break
+			}
+		}
+		if !matched {
+			failures = append(failures, fmt.Sprintf("状态码 %d 不在预期中", result.StatusCode))
+		}
+	} else if result.StatusCode >= 400 {
+		failures = append(failures, fmt.Sprintf("状态码 %d", result.StatusCode))
+	}
+
+	if options.ExpectBody != nil && !options.ExpectBody.Match(result.body) {
+		failures = append(failures, fmt.Sprintf("响应体不匹配 %s", options.ExpectBody))
+	}
+	if options.MaxLatency > 0 && result.Timing.Total > options.MaxLatency {
+		failures = append(failures, fmt.Sprintf("延迟 %v 超过 %v", result.Timing.Total.Round(time.Millisecond), options.MaxLatency))
+	}
+	return failures
+}
+
+// 并发检查列表中的所有URL，按列表顺序输出状态表，有任何URL不满足预期时退出码为1
+func checkURLList(ctx context.Context, filename string, options *URLOptions) {
+	targets, err := readURLList(filename)
+	if err != nil {
+		fmt.Printf("读取URL列表失败: %v\n", err)
+		exitCode = 1
+		return
+	}
+	if len(targets) == 0 {
+		fmt.Printf("%s 中没有URL\n", filename)
+		exitCode = 1
+		return
+	}
+
+	workers := options.Concurrency
+	if workers < 1 {
+		workers = configuredWorkers()
+	}
+
+	client := newHTTPClient(options)
+	results := make([]*URLResult, len(targets))
+	indexes := make(chan int)
+	var wg sync.WaitGroup
+
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for index := range indexes {
+				target, err := normalizeURL(targets[index])
+				var result *URLResult
+				if err != nil {
+					result = &URLResult{URL: targets[index], err: err, Error: err.Error()}
+				} else {
+					result = fetchURL(client, target, options, options.ExpectBody != nil)
+				}
+				result.Failures = checkExpectations(result, options)
+				results[index] = result
+			}
+		}()
+	}
+
+dispatch:
+	for i := range targets {
+		select {
+		case indexes <- i:
+		case <-ctx.Done():
+			break dispatch
+		}
+	}
+	close(indexes)
+	wg.Wait()
+
+	var checked []*URLResult
+	failed := 0
+	for _, result := range results {
+		if result == nil {
+			continue
+		}
+		checked = append(checked, result)
+		if len(result.Failures) > 0 {
+			failed++
+		}
+	}
+	if failed > 0 {
+		exitCode = 1
+	}
+
+	if options.JSON {
+		if checked == nil {
+			checked = []*URLResult{}
+		}
+		data, err := json.MarshalIndent(checked, "", "  ")
+		if err != nil {
+			fmt.Printf("序列化结果失败: %v\n", err)
+			return
+		}
+		fmt.Println(string(data))
+	} else {
+		printURLTable(checked)
+		fmt.Printf("\n共检查 %d 个URL: 通过 %d, 失败 %d\n", len(checked), len(checked)-failed, failed)
+		if len(checked) < len(targets) {
+			fmt.Printf("未检查: %d 个URL\n", len(targets)-len(checked))
+		}
+	}
+	reportCancellation(ctx)
+}
+
+// 打印URL状态表
+func printURLTable(results []*URLResult) {
+	// 中文标题按显示宽度手动对齐
+	fmt.Println("结果  状态         延迟       大小  URL")
+	for _, result := range results {
+		mark := "通过"
+		if len(result.Failures) > 0 {
+			mark = "失败"
+		}
+
+		status, latency, size := "-", "-", "-"
+		if result.err == nil {
+			status = strconv.Itoa(result.StatusCode)
+			latency = result.Timing.Total.Round(time.Millisecond).String()
+			size = formatSize(result.Size)
+		}
+
+		fmt.Printf("%s  %-6s %10s %10s  %s\n", mark, status, latency, size, result.URL)
+		for _, failure := range result.Failures {
+			fmt.Printf("%36s└ %s\n", "", failure)
+		}
+	}
+}
@@ -0,0 +1,189 @@
+package main
+
+import (
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/rsa"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"math"
+	"net"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+)
+
+// 证书链检查结果
+type CertReport struct {
+	Host        string             `json:"host"`
+	Verified    bool               `json:"verified"` // 是否能通过系统根证书验证
+	VerifyError string             `json:"verify_error,omitempty"`
+	Chain       []*CertificateInfo `json:"chain"`
+}
+
+// 单个证书的信息
+type CertificateInfo struct {
+	Subject   string    `json:"subject"`
+	SANs      []string  `json:"sans,omitempty"`
+	Issuer    string    `json:"issuer"`
+	NotBefore time.Time `json:"not_before"`
+	NotAfter  time.Time `json:"not_after"`
+	DaysLeft  int       `json:"days_left"`
+	KeyType   string    `json:"key_type"`
+	IsCA      bool      `json:"is_ca"`
+}
+
+// 获取请求的证书链。请求成功时从最终响应的TLS连接 (跟随重定向、经过代理之后) 读取；
+// 请求因证书校验失败而中止时，单独与失败的地址握手获取证书链，不发送任何HTTP数据。
+// 请求因其他原因失败时返回 nil
+func certificateReport(result *URLResult, timeout time.Duration) (*CertReport, error) {
+	if result.err == nil {
+		if result.tlsState == nil {
+			return nil, fmt.Errorf("%s 不是HTTPS地址", result.finalURL)
+		}
+		return inspectCertificates(result.finalURL.Hostname(), result.tlsState.PeerCertificates)
+	}
+
+	var urlErr *url.Error
+	if !isCertificateError(result.err) || !errors.As(result.err, &urlErr) {
+		return nil, nil
+	}
+	failedURL, err := url.Parse(urlErr.URL)
+	if err != nil {
+		return nil, err
+	}
+	certs, err := handshakeCertificates(failedURL, timeout)
+	if err != nil {
+		return nil, err
+	}
+	return inspectCertificates(failedURL.Hostname(), certs)
+}
+
+// 是否为证书校验失败
+func isCertificateError(err error) bool {
+	var unknownAuthority x509.UnknownAuthorityError
+	var hostname x509.HostnameError
+	var invalid x509.CertificateInvalidError
+	return errors.As(err, &unknownAuthority) || errors.As(err, &hostname) || errors.As(err, &invalid)
+}
+
+// 单独进行一次TLS握手获取对端证书链，握手跳过校验以便查看无效的证书，握手后立即关闭连接
+func handshakeCertificates(target *url.URL, timeout time.Duration) ([]*x509.Certificate, error) {
+	port := target.Port()
+	if port == "" {
+		port = "443"
+	}
+	dialer := &net.Dialer{Timeout: timeout}
+	conn, err := tls.DialWithDialer(dialer, "tcp", net.JoinHostPort(target.Hostname(), port), &tls.Config{
+		ServerName:         target.Hostname(),
+		InsecureSkipVerify: true,
+	})
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+	return conn.ConnectionState().PeerCertificates, nil
+}
+
+// 根据对端证书链生成报告，校验结果由 x509 根据系统根证书和主机名计算
+func inspectCertificates(hostname string, certs []*x509.Certificate) (*CertReport, error) {
+	if len(certs) == 0 {
+		return nil, fmt.Errorf("服务器没有提供证书")
+	}
+
+	report := &CertReport{Host: hostname}
+	for _, cert := range certs {
+		report.Chain = append(report.Chain, describeCertificate(cert))
+	}
+
+	intermediates := x509.NewCertPool()
+	for _, cert := range certs[1:] {
+		intermediates.AddCert(cert)
+	}
+	_, err := certs[0].Verify(x509.VerifyOptions{DNSName: hostname, Intermediates: intermediates})
+	report.Verified = err == nil
+	if err != nil {
+		report.VerifyError = err.Error()
+	}
+	return report, nil
+}
+
+// 提取证书的主要信息
+func describeCertificate(cert *x509.Certificate) *CertificateInfo {
+	info := &CertificateInfo{
+		Subject:   cert.Subject.String(),
+		Issuer:    cert.Issuer.String(),
+		NotBefore: cert.NotBefore,
+		NotAfter:  cert.NotAfter,
+		DaysLeft:  int(math.Floor(time.Until(cert.NotAfter).Hours() / 24)), // 已过期不足一天时为 -1
+		KeyType:   certificateKeyType(cert),
+		IsCA:      cert.IsCA,
+	}
+	info.SANs = append(info.SANs, cert.DNSNames...)
+	for _, ip := range cert.IPAddresses {
+		info.SANs = append(info.SANs, ip.String())
+	}
+	info.SANs = append(info.SANs, cert.EmailAddresses...)
+	for _, uri := range cert.URIs {
+		info.SANs = append(info.SANs, uri.String())
+	}
+	return info
+}
+
+// 公钥类型和长度
+func certificateKeyType(cert *x509.Certificate) string {
+	switch key := cert.PublicKey.(type) {
+	case *rsa.PublicKey:
+		return fmt.Sprintf("RSA %d", key.N.BitLen())
+	case *ecdsa.PublicKey:
+		return fmt.Sprintf("ECDSA %s", key.Curve.Params().Name)
+	case ed25519.PublicKey:
+		return "Ed25519"
+	default:
+		return cert.PublicKeyAlgorithm.String()
+	}
+}
+
+// 打印证书链，返回是否有需要告警的问题 (校验失败或在 warnDays 天内过期)
+func printCertReport(report *CertReport, warnDays int) bool {
+	fmt.Printf("\n证书链 (%s):\n", report.Host)
+	for i, cert := range report.Chain {
+		fmt.Printf("  [%d] %s\n", i, cert.Subject)
+		if len(cert.SANs) > 0 {
+			fmt.Printf("      SAN: %s\n", strings.Join(cert.SANs, ", "))
+		}
+		fmt.Printf("      颁发者: %s\n", cert.Issuer)
+		fmt.Printf("      有效期: %s - %s (剩余 %d 天)\n",
+			cert.NotBefore.Format("2006-01-02"), cert.NotAfter.Format("2006-01-02"), cert.DaysLeft)
+		fmt.Printf("      密钥: %s", cert.KeyType)
+		if cert.IsCA {
+			fmt.Print(", CA")
+		}
+		fmt.Println()
+	}
+
+	if report.Verified {
+		fmt.Println("✅ 证书链可以通过系统根证书验证")
+	} else {
+		fmt.Printf("❌ 证书链验证失败: %s\n", report.VerifyError)
+	}
+	return certWarnings(report, warnDays)
+}
+
+// 检查校验结果和过期时间，将告警输出到标准错误
+func certWarnings(report *CertReport, warnDays int) bool {
+	warned := !report.Verified
+	for _, cert := range report.Chain {
+		if cert.DaysLeft < 0 {
+			fmt.Fprintf(os.Stderr, "警告: 证书 %s 已于 %s 过期\n", cert.Subject, cert.NotAfter.Format("2006-01-02"))
+			warned = true
+		} else if warnDays > 0 && cert.DaysLeft < warnDays {
+			fmt.Fprintf(os.Stderr, "警告: 证书 %s 将在 %d 天后过期 (%s)\n", cert.Subject, cert.DaysLeft, cert.NotAfter.Format("2006-01-02"))
+			warned = true
+		}
+	}
+	return warned
+}
@@ -0,0 +1,257 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"html"
+	"mime"
+	"net/http"
+	"net/url"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// 爬取选项
+type CrawlOptions struct {
+	Depth    int  // --depth 最大链接深度，起始页为 0
+	External bool // --external 同时检查其他主机的链接 (不继续爬取)
+	Workers  int
+}
+
+// 失效的链接
+type BrokenLink struct {
+	URL        string   `json:"url"`
+	StatusCode int      `json:"status_code,omitempty"`
+	Error      string   `json:"error,omitempty"`
+	Referrers  []string `json:"referrers"`
+}
+
+// 爬取结果
+type CrawlReport struct {
+	Checked int           // 检查过的链接数
+	Pages   int           // 解析过的页面数
+	Broken  []*BrokenLink // 按地址排序
+}
+
+// 爬取中的一个链接
+type crawlLink struct {
+	URL   string
+	Depth int
+}
+
+// 页面最多读取的字节数，超出部分中的链接会被忽略
+const maxCrawlPageSize = 10 * 1024 * 1024
+
+var (
+	// HTML 注释，其中的链接不检查
+	htmlComment = regexp.MustCompile(`(?s)<!--.*?-->`)
+	// 开始标签，属性值中可以包含 >
+	htmlStartTag = regexp.MustCompile(`<[a-zA-Z](?:[^>"']|"[^"]*"|'[^']*')*>`)
+	// 标签中的 href 和 src 属性，属性名前必须是空白或 /，避免匹配 data-src 等属性
+	linkAttribute = regexp.MustCompile(`(?i)[\s/](?:href|src)\s*=\s*(?:"([^"]*)"|'([^']*)'|([^\s"'>]+))`)
+)
+
+// 从起始页开始按层并发爬取同一主机的页面，检查所有链接并报告失效的链接
+func crawlSite(ctx context.Context, start string, options *URLOptions, crawl *CrawlOptions) {
+	start, err := normalizeURL(start)
+	if err != nil {
+		fmt.Printf("无效的URL格式: %v\n", err)
+		exitCode = 1
+		return
+	}
+	startURL, _ := url.Parse(start)
+
+	if !options.JSON {
+		fmt.Printf("爬取: %s (深度 %d, 并发 %d)\n", startURL, crawl.Depth, crawl.Workers)
+	}
+
+	report := crawlLinks(ctx, startURL, options, crawl)
+	if len(report.Broken) > 0 {
+		exitCode = 1
+	}
+
+	if options.JSON {
+		data, err := json.MarshalIndent(report.Broken, "", "  ")
+		if err != nil {
+			fmt.Printf("序列化结果失败: %v\n", err)
+			return
+		}
+		fmt.Println(string(data))
+	} else {
+		printBrokenLinks(report)
+	}
+	reportCancellation(ctx)
+}
+
+// 按层爬取：同一主机、深度小于 crawl.Depth 的 HTML 页面会被解析，其中的链接作为下一层
+func crawlLinks(ctx context.Context, startURL *url.URL, options *URLOptions, crawl *CrawlOptions) *CrawlReport {
+	start := *startURL
+	start.Fragment = ""
+	if start.Path == "" {
+		start.Path = "/"
+	}
+
+	workers := crawl.Workers
+	if workers < 1 {
+		workers = 1
+	}
+
+	// 爬取只发送 GET 请求
+	requestOptions := *options
+	requestOptions.Method = ""
+	requestOptions.Data = ""
+	requestOptions.DataFile = ""
+	client := newHTTPClient(&requestOptions)
+
+	report := &CrawlReport{Broken: []*BrokenLink{}}
+	referrers := make(map[string][]string)
+	visited := map[string]bool{start.String(): true}
+	level := []crawlLink{{URL: start.String()}}
+	var mu sync.Mutex
+
+	for len(level) > 0 && ctx.Err() == nil {
+		var next []crawlLink
+		links := make(chan crawlLink)
+		var wg sync.WaitGroup
+
+		for i := 0; i < workers; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				for link := range links {
+					target, _ := url.Parse(link.URL)
+					follow := target.Host == start.Host && link.Depth < crawl.Depth
+
+					// 只保留需要解析的页面的响应体，其他资源读完即丢弃
+					page := false
+					result := fetchURLBody(client, link.URL, &requestOptions, func(resp *http.Response) int64 {
+						page = follow && resp.StatusCode < 400 && isHTMLResponse(resp)
+						if page {
+							return maxCrawlPageSize
+						}
+						return 0
+					})
+					var found []string
+					if page && result.err == nil {
+						found = extractLinks(result.finalURL, result.body)
+					}
+
+					mu.Lock()
+					report.Checked++
+					if page && result.err == nil {
+						report.Pages++
+					}
+					if result.err != nil || result.StatusCode >= 400 {
+						item := &BrokenLink{URL: link.URL, StatusCode: result.StatusCode}
+						if result.err != nil {
+							item.Error = probeErrorKind(result.err)
+						}
+						report.Broken = append(report.Broken, item)
+					}
+					for _, found := range found {
+						foundURL, _ := url.Parse(found)
+						if foundURL.Host != start.Host && !crawl.External {
+							continue
+						}
+						referrers[found] = append(referrers[found], link.URL)
+						if !visited[found] {
+							visited[found] = true
+							next = append(next, crawlLink{URL: found, Depth: link.Depth + 1})
+						}
+					}
+					mu.Unlock()
+				}
+			}()
+		}
+
+	dispatch:
+		for _, link := range level {
+			select {
+			case links <- link:
+			case <-ctx.Done():
+				break dispatch
+			}
+		}
+		close(links)
+		wg.Wait()
+
+		sort.Slice(next, func(i, j int) bool { return next[i].URL < next[j].URL })
+		level = next
+	}
+
+	// 失效链接的引用页可能在其被检查后才发现，全部完成后再填入
+	sort.Slice(report.Broken, func(i, j int) bool { return report.Broken[i].URL < report.Broken[j].URL })
+	for _, item := range report.Broken {
+		item.Referrers = uniqueStrings(referrers[item.URL])
+	}
+	return report
+}
+
+// 响应是否为 HTML 页面
+func isHTMLResponse(resp *http.Response) bool {
+	mediaType, _, err := mime.ParseMediaType(resp.Header.Get("Content-Type"))
+	return err == nil && (mediaType == "text/html" || mediaType == "application/xhtml+xml")
+}
+
+// 提取页面标签中的链接，转换为去掉片段的绝对地址，只保留 http/https
+func extractLinks(base *url.URL, body []byte) []string {
+	var links []string
+	seen := make(map[string]bool)
+	body = htmlComment.ReplaceAll(body, nil)
+	for _, tag := range htmlStartTag.FindAll(body, -1) {
+		for _, match := range linkAttribute.FindAllSubmatch(tag, -1) {
+			raw := string(match[1]) + string(match[2]) + string(match[3])
+			raw = strings.TrimSpace(html.UnescapeString(raw))
+			if raw == "" || strings.HasPrefix(raw, "#") {
+				continue
+			}
+
+			ref, err := url.Parse(raw)
+			if err != nil {
+				continue
+			}
+			link := base.ResolveReference(ref)
+			if link.Scheme != "http" && link.Scheme != "https" {
+				continue
+			}
+			link.Fragment = ""
+			if !seen[link.String()] {
+				seen[link.String()] = true
+				links = append(links, link.String())
+			}
+		}
+	}
+	return links
+}
+
+// 去重并排序，结果不为 nil
+func uniqueStrings(values []string) []string {
+	seen := make(map[string]bool, len(values))
+	unique := []string{}
+	for _, value := range values {
+		if !seen[value] {
+			seen[value] = true
+			unique = append(unique, value)
+		}
+	}
+	sort.Strings(unique)
+	return unique
+}
+
+// 打印失效链接和引用页
+func printBrokenLinks(report *CrawlReport) {
+	fmt.Printf("检查了 %d 个链接 (%d 个页面)，失效 %d 个\n", report.Checked, report.Pages, len(report.Broken))
+	for _, item := range report.Broken {
+		if item.Error != "" {
+			fmt.Printf("\n❌ %s\n   错误: %s\n", item.URL, item.Error)
+		} else {
+			fmt.Printf("\n❌ %s\n   状态码: %d\n", item.URL, item.StatusCode)
+		}
+		for _, referrer := range item.Referrers {
+			fmt.Printf("   来自: %s\n", referrer)
+		}
+	}
+}
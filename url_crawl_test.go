@@ -0,0 +1,115 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"reflect"
+	"testing"
+	"time"
+)
+
+// 启动一个返回固定页面的测试服务器，未列出的路径返回 404
+func newCrawlTestServer(t *testing.T, pages map[string]string) *httptest.Server {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/slow":
+			time.Sleep(time.Second)
+		case "/logo.png":
+			w.Header().Set("Content-Type", "image/png")
+			w.Write([]byte("png"))
+			return
+		}
+		page, ok := pages[r.URL.Path]
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		fmt.Fprint(w, page)
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestCrawlLinks(t *testing.T) {
+	external := newCrawlTestServer(t, map[string]string{
+		"/ok": `<a href="/not-followed">不会被爬取</a>`,
+	})
+	site := newCrawlTestServer(t, map[string]string{
+		"/": `<html><body>
+<a href="a.html#top">A</a> <a href='/missing'>M</a>
+<img alt="x > y" src=/logo.png> <div data-src="/data-src"></div>
+<!-- <a href="/commented"> -->
+<p>src=/text-only</p>
+<a href="mailto:a@example.com">邮件</a> <a href="#section">锚点</a>
+<a href="` + external.URL + `/ok">外部</a> <a href="` + external.URL + `/gone">外部失效</a>
+</body></html>`,
+		"/a.html": `<a href="/">首页</a> <a href="b.html">B</a> <a href="/missing">M</a>
+<link rel="stylesheet" href="gone.css"> <script src="/slow"></script>`,
+		"/b.html": `<a href="/deep-missing">D</a>`,
+	})
+	startURL, _ := url.Parse(site.URL)
+
+	crawl := func(depth int, externalLinks bool) map[string][]string {
+		options := &URLOptions{Timeout: 300 * time.Millisecond}
+		report := crawlLinks(context.Background(), startURL, options, &CrawlOptions{Depth: depth, External: externalLinks, Workers: 4})
+		broken := make(map[string][]string)
+		for _, item := range report.Broken {
+			if (item.StatusCode == 0) == (item.Error == "") {
+				t.Errorf("%s: 状态码 %d, 错误 %q", item.URL, item.StatusCode, item.Error)
+			}
+			broken[item.URL] = item.Referrers
+		}
+		return broken
+	}
+
+	tests := []struct {
+		name     string
+		depth    int
+		external bool
+		want     map[string][]string
+	}{
+		{
+			name:  "只检查起始页",
+			depth: 0,
+			want:  map[string][]string{},
+		},
+		{
+			name:  "深度1",
+			depth: 1,
+			want: map[string][]string{
+				site.URL + "/missing": {site.URL + "/"},
+			},
+		},
+		{
+			name:  "深度3",
+			depth: 3,
+			want: map[string][]string{
+				site.URL + "/missing":      {site.URL + "/", site.URL + "/a.html"},
+				site.URL + "/gone.css":     {site.URL + "/a.html"},
+				site.URL + "/slow":         {site.URL + "/a.html"},
+				site.URL + "/deep-missing": {site.URL + "/b.html"},
+			},
+		},
+		{
+			name:     "外部链接只检查不爬取",
+			depth:    1,
+			external: true,
+			want: map[string][]string{
+				site.URL + "/missing":  {site.URL + "/"},
+				external.URL + "/gone": {site.URL + "/"},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := crawl(tt.depth, tt.external); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("失效链接 = %v, 期望 %v", got, tt.want)
+			}
+		})
+	}
+}
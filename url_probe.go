@@ -0,0 +1,212 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"sort"
+	"sync"
+	"time"
+)
+
+// 重复探测的统计结果
+type ProbeSummary struct {
+	URL         string         `json:"url"`
+	Requests    int            `json:"requests"`
+	Concurrency int            `json:"concurrency"`
+	Errors      int            `json:"errors"`
+	ErrorRate   float64        `json:"error_rate"`
+	Duration    float64        `json:"duration_ms"`
+	RPS         float64        `json:"requests_per_second"`
+	Latency     LatencyStats   `json:"latency_ms"`
+	Statuses    map[int]int    `json:"statuses"`
+	ErrorKinds  map[string]int `json:"error_kinds,omitempty"`
+}
+
+// 延迟统计，单位毫秒
+type LatencyStats struct {
+	Min float64 `json:"min"`
+	Avg float64 `json:"avg"`
+	P50 float64 `json:"p50"`
+	P90 float64 `json:"p90"`
+	P99 float64 `json:"p99"`
+	Max float64 `json:"max"`
+}
+
+// 使用 concurrency 个并发连接重复请求 count 次并汇总延迟、状态码和错误
+func probeURL(ctx context.Context, target string, options *URLOptions) {
+	target, err := normalizeURL(target)
+	if err != nil {
+		fmt.Printf("无效的URL格式: %v\n", err)
+		exitCode = 1
+		return
+	}
+
+	concurrency := options.Concurrency
+	if concurrency < 1 {
+		concurrency = 1
+	}
+	if concurrency > options.Count {
+		concurrency = options.Count
+	}
+
+	// 所有请求共用一个客户端，并允许每个并发保持一个空闲连接
+	client := newHTTPClient(options)
+	if transport, ok := client.Transport.(*http.Transport); ok {
+		transport.MaxIdleConnsPerHost = concurrency
+	}
+
+	if !options.JSON {
+		fmt.Printf("探测URL: %s (%d 次, 并发 %d)\n", target, options.Count, concurrency)
+	}
+
+	results := make([]*URLResult, 0, options.Count)
+	indexes := make(chan int)
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	start := time.Now()
+
+	for i := 0; i < concurrency; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for range indexes {
+				result := fetchURL(client, target, options, false)
+				mu.Lock()
+				results = append(results, result)
+				mu.Unlock()
+			}
+		}()
+	}
+
+dispatch:
+	for i := 0; i < options.Count; i++ {
+		select {
+		case indexes <- i:
+		case <-ctx.Done():
+			break dispatch
+		}
+	}
+	close(indexes)
+	wg.Wait()
+
+	summary := summarizeProbe(target, results, concurrency, time.Since(start))
+	if summary.Errors > 0 {
+		exitCode = 1
+	}
+
+	if options.JSON {
+		data, err := json.MarshalIndent(summary, "", "  ")
+		if err != nil {
+			fmt.Printf("序列化结果失败: %v\n", err)
+			return
+		}
+		fmt.Println(string(data))
+	} else {
+		printProbeSummary(summary)
+	}
+	reportCancellation(ctx)
+}
+
+// 汇总探测结果
+func summarizeProbe(target string, results []*URLResult, concurrency int, elapsed time.Duration) *ProbeSummary {
+	summary := &ProbeSummary{
+		URL:         target,
+		Requests:    len(results),
+		Concurrency: concurrency,
+		Duration:    durationMs(elapsed),
+		Statuses:    make(map[int]int),
+		ErrorKinds:  make(map[string]int),
+	}
+	if elapsed > 0 {
+		summary.RPS = float64(len(results)) / elapsed.Seconds()
+	}
+
+	var latencies []time.Duration
+	for _, result := range results {
+		if result.err != nil {
+			summary.Errors++
+			summary.ErrorKinds[probeErrorKind(result.err)]++
+			continue
+		}
+		summary.Statuses[result.StatusCode]++
+		latencies = append(latencies, result.Timing.Total)
+	}
+	if len(results) > 0 {
+		summary.ErrorRate = float64(summary.Errors) / float64(len(results))
+	}
+
+	if len(latencies) > 0 {
+		sort.Slice(latencies, func(i, j int) bool { return latencies[i] < latencies[j] })
+		var total time.Duration
+		for _, latency := range latencies {
+			total += latency
+		}
+		summary.Latency = LatencyStats{
+			Min: durationMs(latencies[0]),
+			Avg: durationMs(total / time.Duration(len(latencies))),
+			P50: durationMs(percentile(latencies, 50)),
+			P90: durationMs(percentile(latencies, 90)),
+			P99: durationMs(percentile(latencies, 99)),
+			Max: durationMs(latencies[len(latencies)-1]),
+		}
+	}
+	return summary
+}
+
+// 已排序延迟的百分位数 (最近秩法)
+func percentile(sorted []time.Duration, p int) time.Duration {
+	rank := (p*len(sorted) + 99) / 100
+	if rank < 1 {
+		rank = 1
+	}
+	return sorted[rank-1]
+}
+
+// 毫秒数，保留微秒精度
+func durationMs(d time.Duration) float64 {
+	return float64(d.Microseconds()) / 1000
+}
+
+// 去掉错误中重复的方法和URL，便于按原因分组
+func probeErrorKind(err error) string {
+	var urlErr *url.Error
+	if errors.As(err, &urlErr) {
+		return urlErr.Err.Error()
+	}
+	return err.Error()
+}
+
+// 打印探测统计
+func printProbeSummary(summary *ProbeSummary) {
+	fmt.Printf("完成 %d 次请求，耗时 %.0fms，%.1f 次/秒\n", summary.Requests, summary.Duration, summary.RPS)
+	if summary.Requests > summary.Errors {
+		latency := summary.Latency
+		fmt.Printf("延迟 (ms): 最小 %.2f, 平均 %.2f, P50 %.2f, P90 %.2f, P99 %.2f, 最大 %.2f\n",
+			latency.Min, latency.Avg, latency.P50, latency.P90, latency.P99, latency.Max)
+	}
+
+	codes := make([]int, 0, len(summary.Statuses))
+	for code := range summary.Statuses {
+		codes = append(codes, code)
+	}
+	sort.Ints(codes)
+	fmt.Println("状态码:")
+	for _, code := range codes {
+		count := summary.Statuses[code]
+		fmt.Printf("  %d  %6d  (%.1f%%)\n", code, count, percentOf(int64(count), int64(summary.Requests)))
+	}
+
+	fmt.Printf("错误: %d (%.1f%%)\n", summary.Errors, summary.ErrorRate*100)
+	kinds := make([]string, 0, len(summary.ErrorKinds))
+	for kind := range summary.ErrorKinds {
+		kinds = append(kinds, kind)
+	}
+	sort.Slice(kinds, func(i, j int) bool { return summary.ErrorKinds[kinds[i]] > summary.ErrorKinds[kinds[j]] })
+	for _, kind := range kinds {
+		fmt.Printf("  %6d  %s\n", summary.ErrorKinds[kind], kind)
+	}
+}
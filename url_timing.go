@@ -0,0 +1,116 @@
+package main
+
+import (
+	"crypto/tls"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptrace"
+	"sync"
+	"time"
+)
+
+// 请求各阶段的耗时。连接被复用时 DNS、TCP 和 TLS 为 0；
+// 发生重定向时各阶段为最后一次请求的耗时，首字节时间和总耗时从第一次请求开始计算
+type RequestTiming struct {
+	DNS              time.Duration
+	Connect          time.Duration
+	TLS              time.Duration
+	ServerProcessing time.Duration // 请求发送完成到收到首字节
+	TTFB             time.Duration // 开始到收到首字节
+	Transfer         time.Duration // 首字节到读完响应体
+	Total            time.Duration
+	Reused           bool
+
+	mu                                      sync.Mutex
+	start, dnsStart, connectStart, tlsStart time.Time
+	wroteRequest, firstByte                 time.Time
+}
+
+// 为请求挂载 httptrace，返回新请求和用于记录耗时的 RequestTiming
+func traceRequest(req *http.Request) (*http.Request, *RequestTiming) {
+	t := &RequestTiming{start: time.Now()}
+	trace := &httptrace.ClientTrace{
+		DNSStart: func(httptrace.DNSStartInfo) { t.mark(&t.dnsStart) },
+		DNSDone:  func(httptrace.DNSDoneInfo) { t.since(&t.DNS, t.dnsStart) },
+		ConnectStart: func(string, string) {
+			t.mark(&t.connectStart)
+		},
+		ConnectDone: func(network, addr string, err error) {
+			if err == nil {
+				t.since(&t.Connect, t.connectStart)
+			}
+		},
+		TLSHandshakeStart: func() { t.mark(&t.tlsStart) },
+		TLSHandshakeDone: func(_ tls.ConnectionState, err error) {
+			if err == nil {
+				t.since(&t.TLS, t.tlsStart)
+			}
+		},
+		GotConn: func(info httptrace.GotConnInfo) {
+			t.mu.Lock()
+			t.Reused = info.Reused
+			t.mu.Unlock()
+		},
+		WroteRequest: func(httptrace.WroteRequestInfo) { t.mark(&t.wroteRequest) },
+		GotFirstResponseByte: func() {
+			t.mark(&t.firstByte)
+			t.since(&t.ServerProcessing, t.wroteRequest)
+		},
+	}
+	return req.WithContext(httptrace.WithClientTrace(req.Context(), trace)), t
+}
+
+func (t *RequestTiming) mark(at *time.Time) {
+	t.mu.Lock()
+	*at = time.Now()
+	t.mu.Unlock()
+}
+
+func (t *RequestTiming) since(d *time.Duration, from time.Time) {
+	t.mu.Lock()
+	*d = time.Since(from)
+	t.mu.Unlock()
+}
+
+// 读完响应体后计算首字节时间、传输时间和总耗时
+func (t *RequestTiming) finish() {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	now := time.Now()
+	t.Total = now.Sub(t.start)
+	if !t.firstByte.IsZero() {
+		t.TTFB = t.firstByte.Sub(t.start)
+		t.Transfer = now.Sub(t.firstByte)
+	}
+}
+
+// JSON 中以毫秒输出各阶段耗时
+func (t *RequestTiming) MarshalJSON() ([]byte, error) {
+	ms := func(d time.Duration) float64 { return float64(d.Microseconds()) / 1000 }
+	return json.Marshal(struct {
+		DNS              float64 `json:"dns_ms"`
+		Connect          float64 `json:"connect_ms"`
+		TLS              float64 `json:"tls_ms"`
+		ServerProcessing float64 `json:"server_processing_ms"`
+		TTFB             float64 `json:"ttfb_ms"`
+		Transfer         float64 `json:"transfer_ms"`
+		Total            float64 `json:"total_ms"`
+		Reused           bool    `json:"reused"`
+	}{ms(t.DNS), ms(t.Connect), ms(t.TLS), ms(t.ServerProcessing), ms(t.TTFB), ms(t.Transfer), ms(t.Total), t.Reused})
+}
+
+// 输出耗时分解，类似 httpstat
+func printTiming(t *RequestTiming) {
+	fmt.Println("   耗时分解:")
+	if t.Reused {
+		fmt.Println("     (复用已有连接)")
+	}
+	fmt.Printf("     DNS解析:    %v\n", t.DNS.Round(time.Microsecond))
+	fmt.Printf("     TCP连接:    %v\n", t.Connect.Round(time.Microsecond))
+	fmt.Printf("     TLS握手:    %v\n", t.TLS.Round(time.Microsecond))
+	fmt.Printf("     服务器处理: %v\n", t.ServerProcessing.Round(time.Microsecond))
+	fmt.Printf("     首字节时间: %v\n", t.TTFB.Round(time.Microsecond))
+	fmt.Printf("     内容传输:   %v\n", t.Transfer.Round(time.Microsecond))
+	fmt.Printf("     总耗时:     %v\n", t.Total.Round(time.Microsecond))
+}
@@ -0,0 +1,320 @@
+package main
+
+import (
+	"crypto/tls"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+)
+
+// URL测试选项
+type URLOptions struct {
+	Method     string      // -X 请求方法，默认 GET，有请求体时为 POST
+	Headers    http.Header // -H 请求头
+	Data       string      // -d 请求体
+	DataFile   string      // --data-file 从文件读取请求体
+	Timeout    time.Duration
+	Insecure   bool // --insecure 不校验TLS证书
+	NoRedirect bool // --no-redirect 不跟随重定向
+	ShowBody   bool // --body 输出响应体
+	JSON       bool // --json 以JSON输出结果和耗时
+
+	Count       int // --count 重复请求次数，大于1时输出延迟统计
+	Concurrency int // --concurrency 并发请求数
+
+	// 预期条件，用于 --file 批量检查和单个URL的冒烟测试
+	ExpectStatus []int          // --expect-status 允许的状态码
+	ExpectBody   *regexp.Regexp // --expect-body 响应体需要匹配的正则表达式
+	MaxLatency   time.Duration  // --max-latency 最大总耗时
+
+	Cert     bool // --cert 输出HTTPS证书链
+	WarnDays int  // --warn-days 证书在 N 天内过期时告警
+}
+
+// 补全URL协议
+func normalizeURL(target string) (string, error) {
+	// 先补全协议再解析，否则 host:port 形式会被当作协议
+	if !strings.Contains(target, "://") {
+		target = "http://" + target
+	}
+	parsedURL, err := url.Parse(target)
+	if err != nil {
+		return "", err
+	}
+	if parsedURL.Host == "" {
+		return "", fmt.Errorf("缺少主机名: %s", target)
+	}
+	return target, nil
+}
+
+// 根据选项创建HTTP客户端
+func newHTTPClient(options *URLOptions) *http.Client {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	if options.Insecure {
+		transport.TLSClientConfig = &tls.Config{InsecureSkipVerify: true}
+	}
+
+	client := &http.Client{Transport: transport, Timeout: options.Timeout}
+	if options.NoRedirect {
+		client.CheckRedirect = func(req *http.Request, via []*http.Request) error {
+			return http.ErrUseLastResponse
+		}
+	}
+	return client
+}
+
+// 根据选项创建请求
+func newURLRequest(target string, options *URLOptions) (*http.Request, error) {
+	var body io.Reader
+	hasBody := false
+	switch {
+	case options.DataFile != "":
+		data, err := os.ReadFile(options.DataFile)
+		if err != nil {
+			return nil, fmt.Errorf("读取请求体文件失败: %v", err)
+		}
+		body = strings.NewReader(string(data))
+		hasBody = true
+	case options.Data != "":
+		body = strings.NewReader(options.Data)
+		hasBody = true
+	}
+
+	method := options.Method
+	if method == "" {
+		method = http.MethodGet
+		if hasBody {
+			method = http.MethodPost
+		}
+	}
+
+	req, err := http.NewRequest(strings.ToUpper(method), target, body)
+	if err != nil {
+		return nil, err
+	}
+	for key, values := range options.Headers {
+		for _, value := range values {
+			req.Header.Add(key, value)
+		}
+	}
+	if hasBody && req.Header.Get("Content-Type") == "" {
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	}
+	// Host 头需要单独设置到请求上
+	if host := options.Headers.Get("Host"); host != "" {
+		req.Host = host
+	}
+	return req, nil
+}
+
+// 解析 -H 'Key: Value' 形式的请求头
+func parseHeader(header string, headers http.Header) error {
+	key, value, ok := strings.Cut(header, ":")
+	key = strings.TrimSpace(key)
+	if !ok || key == "" {
+		return fmt.Errorf("无效的请求头: %s (格式应为 'Key: Value')", header)
+	}
+	headers.Add(key, strings.TrimSpace(value))
+	return nil
+}
+
+// 单次请求的结果
+type URLResult struct {
+	URL        string         `json:"url"`
+	Method     string         `json:"method"`
+	StatusCode int            `json:"status_code,omitempty"`
+	Status     string         `json:"status,omitempty"`
+	Proto      string         `json:"proto,omitempty"`
+	Size       int64          `json:"size"`
+	Headers    http.Header    `json:"headers,omitempty"`
+	Timing     *RequestTiming `json:"timing,omitempty"`
+	Error      string         `json:"error,omitempty"`
+	Failures   []string       `json:"failures,omitempty"` // 不满足的预期条件
+	TLS        *CertReport    `json:"tls,omitempty"`      // --cert 证书链
+
+	body     []byte
+	finalURL *url.URL             // 跟随重定向后的地址
+	tlsState *tls.ConnectionState // 最终响应的TLS连接状态，HTTP 时为 nil
+	err      error
+}
+
+// 发送一次请求并读完响应体，keepBody 为 true 时保留响应体
+func fetchURL(client *http.Client, target string, options *URLOptions, keepBody bool) *URLResult {
+	return fetchURLBody(client, target, options, func(*http.Response) int64 {
+		if keepBody {
+			return -1
+		}
+		return 0
+	})
+}
+
+// 发送一次请求并读完响应体。收到响应头后由 keep 决定最多保留多少字节的响应体，
+// -1 表示不限制，超出部分读完后丢弃
+func fetchURLBody(client *http.Client, target string, options *URLOptions, keep func(resp *http.Response) int64) *URLResult {
+	result := &URLResult{URL: target}
+	fail := func(err error) *URLResult {
+		result.err = err
+		result.Error = err.Error()
+		return result
+	}
+
+	req, err := newURLRequest(target, options)
+	if err != nil {
+		return fail(err)
+	}
+	result.Method = req.Method
+
+	req, timing := traceRequest(req)
+	resp, err := client.Do(req)
+	if err != nil {
+		return fail(err)
+	}
+	defer resp.Body.Close()
+
+	result.StatusCode = resp.StatusCode
+	result.Status = resp.Status
+	result.Proto = resp.Proto
+	result.Headers = resp.Header
+	result.finalURL = resp.Request.URL
+	result.tlsState = resp.TLS
+	if limit := keep(resp); limit != 0 {
+		var reader io.Reader = resp.Body
+		if limit > 0 {
+			reader = io.LimitReader(resp.Body, limit)
+		}
+		result.body, err = io.ReadAll(reader)
+		result.Size = int64(len(result.body))
+	}
+	if err == nil {
+		var rest int64
+		rest, err = io.Copy(io.Discard, resp.Body)
+		result.Size += rest
+	}
+	timing.finish()
+	result.Timing = timing
+	if err != nil {
+		return fail(fmt.Errorf("读取响应失败: %v", err))
+	}
+	return result
+}
+
+// 测试URL连接，输出状态、耗时分解和完整的响应头
+func testURL(target string, options *URLOptions) {
+	target, err := normalizeURL(target)
+	if err != nil {
+		fmt.Printf("无效的URL格式: %v\n", err)
+		exitCode = 1
+		return
+	}
+
+	if !options.JSON {
+		method := options.Method
+		if method == "" {
+			method = "GET"
+			if options.Data != "" || options.DataFile != "" {
+				method = "POST"
+			}
+		}
+		fmt.Printf("测试URL: %s %s\n", strings.ToUpper(method), target)
+	}
+
+	result := fetchURL(newHTTPClient(options), target, options, options.ShowBody || options.ExpectBody != nil)
+	if result.err != nil {
+		exitCode = 1
+	}
+	if hasExpectations(options) {
+		result.Failures = checkExpectations(result, options)
+		if len(result.Failures) > 0 {
+			exitCode = 1
+		}
+	}
+
+	var certErr error
+	if options.Cert {
+		result.TLS, certErr = certificateReport(result, options.Timeout)
+		if certErr != nil {
+			exitCode = 1
+		}
+	}
+
+	if options.JSON {
+		data, err := json.MarshalIndent(result, "", "  ")
+		if err != nil {
+			fmt.Printf("序列化结果失败: %v\n", err)
+			return
+		}
+		fmt.Println(string(data))
+		if certErr != nil {
+			fmt.Fprintf(os.Stderr, "获取证书失败: %v\n", certErr)
+		} else if result.TLS != nil && certWarnings(result.TLS, options.WarnDays) {
+			exitCode = 1
+		}
+		return
+	}
+
+	printURLResult(result)
+
+	if options.Cert {
+		if certErr != nil {
+			fmt.Printf("\n❌ 获取证书失败: %v\n", certErr)
+		} else if result.TLS != nil && printCertReport(result.TLS, options.WarnDays) {
+			exitCode = 1
+		}
+	}
+
+	if options.ShowBody && result.err == nil {
+		fmt.Println()
+		os.Stdout.Write(result.body)
+		if len(result.body) > 0 && result.body[len(result.body)-1] != '\n' {
+			fmt.Println()
+		}
+	}
+}
+
+// 输出请求结果、耗时分解、响应头和未满足的预期条件
+func printURLResult(result *URLResult) {
+	if result.err != nil {
+		fmt.Printf("❌ 请求失败: %v\n", result.err)
+		return
+	}
+
+	fmt.Printf("✅ 响应时间: %v\n", result.Timing.Total)
+	fmt.Printf("   状态码: %d %s\n", result.StatusCode, result.Status)
+	fmt.Printf("   内容长度: %d bytes\n", result.Size)
+	fmt.Printf("   内容类型: %s\n", result.Headers.Get("Content-Type"))
+	fmt.Printf("   服务器: %s\n", result.Headers.Get("Server"))
+	printTiming(result.Timing)
+
+	fmt.Printf("\n%s %s\n", result.Proto, result.Status)
+	printHeaders(result.Headers)
+
+	for _, failure := range result.Failures {
+		fmt.Printf("❌ %s\n", failure)
+	}
+}
+
+// 是否指定了预期条件
+func hasExpectations(options *URLOptions) bool {
+	return len(options.ExpectStatus) > 0 || options.ExpectBody != nil || options.MaxLatency > 0
+}
+
+// 按名称排序输出响应头
+func printHeaders(headers http.Header) {
+	keys := make([]string, 0, len(headers))
+	for key := range headers {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		for _, value := range headers[key] {
+			fmt.Printf("%s: %s\n", key, value)
+		}
+	}
+}
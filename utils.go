@@ -2,18 +2,14 @@ package main
 
 import (
 	"bufio"
-	"crypto/md5"
-	"crypto/sha256"
+	"context"
 	"fmt"
 	"io"
-	"net/http"
-	"net/url"
 	"os"
 	"path/filepath"
 	"regexp"
 	"runtime"
 	"strings"
-	"sync"
 	"time"
 )
 
@@ -101,6 +97,21 @@ func shouldUseColor(colorOption string) bool {
 	}
 }
 
+// 格式化字节数为易读的大小
+func formatSize(bytes int64) string {
+	units := []string{"B", "KB", "MB", "GB", "TB", "PB"}
+	size := float64(bytes)
+	unit := 0
+	for size >= 1024 && unit < len(units)-1 {
+		size /= 1024
+		unit++
+	}
+	if unit == 0 {
+		return fmt.Sprintf("%d B", bytes)
+	}
+	return fmt.Sprintf("%.2f %s", size, units[unit])
+}
+
 // Grep选项
 type GrepOptions struct {
 	IgnoreCase   bool
@@ -112,6 +123,8 @@ type GrepOptions struct {
 	Color        string // "auto", "always", "never"
 	Text         bool   // 强制将二进制文件作为文本处理
 	Context      int    // -C 上下文行数
+	Walk         WalkOptions // 递归搜索时的遍历选项
+	Timeout      time.Duration // --timeout 整体运行时间上限，0 表示不限制
 }
 
 // Grep搜索结果
@@ -122,74 +135,12 @@ type GrepResult struct {
 	Matches  []string
 }
 
-// 文件哈希计算
-func calculateFileHash(filename string, hashType string) (string, error) {
-	file, err := os.Open(filename)
-	if err != nil {
-		return "", err
-	}
-	defer file.Close()
-
-	switch hashType {
-	case "md5":
-		hash := md5.New()
-		if _, err := io.Copy(hash, file); err != nil {
-			return "", err
-		}
-		return fmt.Sprintf("%x", hash.Sum(nil)), nil
-	case "sha256":
-		hash := sha256.New()
-		if _, err := io.Copy(hash, file); err != nil {
-			return "", err
-		}
-		return fmt.Sprintf("%x", hash.Sum(nil)), nil
-	default:
-		return "", fmt.Errorf("不支持的哈希类型: %s", hashType)
-	}
-}
-
-// URL验证和测试
-func testURL(target string) {
-	// 验证URL格式
-	parsedURL, err := url.Parse(target)
-	if err != nil {
-		fmt.Printf("无效的URL格式: %v\n", err)
-		return
-	}
-
-	if parsedURL.Scheme == "" {
-		target = "http://" + target
-	}
-
-	fmt.Printf("测试URL: %s\n", target)
-	
-	start := time.Now()
-	resp, err := http.Get(target)
-	duration := time.Since(start)
-
-	if err != nil {
-		fmt.Printf("❌ 请求失败: %v\n", err)
-		return
-	}
-	defer resp.Body.Close()
-
-	fmt.Printf("✅ 响应时间: %v\n", duration)
-	fmt.Printf("   状态码: %d %s\n", resp.StatusCode, resp.Status)
-	fmt.Printf("   内容长度: %d bytes\n", resp.ContentLength)
-	fmt.Printf("   内容类型: %s\n", resp.Header.Get("Content-Type"))
-	fmt.Printf("   服务器: %s\n", resp.Header.Get("Server"))
-}
-
 // 文件查找
-func findFiles(dir string, pattern string) {
+func findFiles(dir string, pattern string, walkOptions *WalkOptions) {
 	fmt.Printf("在 %s 中查找匹配 '%s' 的文件:\n", dir, pattern)
 	
 	count := 0
-	err := filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
-		if err != nil {
-			return err
-		}
-		
+	stats, err := walkTree(dir, walkOptions, func(path string, info os.FileInfo) error {
 		if !info.IsDir() && strings.Contains(strings.ToLower(info.Name()), strings.ToLower(pattern)) {
 			fmt.Printf("  %s (%d bytes)\n", path, info.Size())
 			count++
@@ -203,95 +154,11 @@ func findFiles(dir string, pattern string) {
 	}
 	
 	fmt.Printf("共找到 %d 个文件\n", count)
+	printWalkStats(stats)
 }
 
-// 文件大小统计
-func analyzeDirectory(dir string) {
-	fmt.Printf("分析目录: %s\n", dir)
-	
-	var totalSize int64
-	var fileCount int
-	var dirCount int
-	
-	err := filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
-		if err != nil {
-			return err
-		}
-		
-		if info.IsDir() {
-			dirCount++
-		} else {
-			fileCount++
-			totalSize += info.Size()
-		}
-		return nil
-	})
-	
-	if err != nil {
-		fmt.Printf("分析失败: %v\n", err)
-		return
-	}
-	
-	fmt.Printf("结果:\n")
-	fmt.Printf("  文件数: %d\n", fileCount)
-	fmt.Printf("  目录数: %d\n", dirCount)
-	fmt.Printf("  总大小: %.2f MB\n", float64(totalSize)/1024/1024)
-}
-
-// 并发文件处理示例
-func processFiles(dir string, workers int) {
-	fmt.Printf("使用 %d 个工作线程处理文件...\n", workers)
-	
-	filesChan := make(chan string, 100)
-	resultsChan := make(chan string, 100)
-	var wg sync.WaitGroup
-	
-	// 启动工作线程
-	for i := 0; i < workers; i++ {
-		wg.Add(1)
-		go func(id int) {
-			defer wg.Done()
-			for filename := range filesChan {
-				// 模拟文件处理
-				time.Sleep(100 * time.Millisecond)
-				resultsChan <- fmt.Sprintf("工作线程 %d 处理: %s", id, filename)
-			}
-		}(i)
-	}
-	
-	// 发送文件到工作线程
-	go func() {
-		defer close(filesChan)
-		filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
-			if err != nil {
-				return err
-			}
-			if !info.IsDir() {
-				filesChan <- path
-			}
-			return nil
-		})
-	}()
-	
-	// 收集结果
-	go func() {
-		wg.Wait()
-		close(resultsChan)
-	}()
-	
-	count := 0
-	for result := range resultsChan {
-		fmt.Printf("  %s\n", result)
-		count++
-	}
-	
-	fmt.Printf("处理完成，共处理 %d 个文件\n", count)
-}
-
-
-
 // Grep搜索主函数
-func grepSearch(pattern string, targets []string, options *GrepOptions) {
+func grepSearch(ctx context.Context, pattern string, targets []string, options *GrepOptions) {
 	// 编译正则表达式
 	var regex *regexp.Regexp
 	var err error
@@ -310,17 +177,21 @@ func grepSearch(pattern string, targets []string, options *GrepOptions) {
 	totalMatches := 0
 	
 	for _, target := range targets {
-		matches := processGrepTarget(target, regex, options)
+		if ctx.Err() != nil {
+			break
+		}
+		matches := processGrepTarget(ctx, target, regex, options)
 		totalMatches += matches
 	}
 	
 	if options.CountOnly {
 		fmt.Printf("总匹配数: %d\n", totalMatches)
 	}
+	reportCancellation(ctx)
 }
 
 // 处理grep目标（文件或目录）
-func processGrepTarget(target string, regex *regexp.Regexp, options *GrepOptions) int {
+func processGrepTarget(ctx context.Context, target string, regex *regexp.Regexp, options *GrepOptions) int {
 	info, err := os.Stat(target)
 	if err != nil {
 		fmt.Printf("错误: %v\n", err)
@@ -329,7 +200,7 @@ func processGrepTarget(target string, regex *regexp.Regexp, options *GrepOptions
 	
 	if info.IsDir() {
 		if options.Recursive {
-			return grepInDirectory(target, regex, options)
+			return grepInDirectory(ctx, target, regex, options)
 		} else {
 			fmt.Printf("跳过目录: %s (使用 -r 选项递归搜索)\n", target)
 			return 0
@@ -344,14 +215,10 @@ func processGrepTarget(target string, regex *regexp.Regexp, options *GrepOptions
 }
 
 // 在目录中递归搜索
-func grepInDirectory(dir string, regex *regexp.Regexp, options *GrepOptions) int {
+func grepInDirectory(ctx context.Context, dir string, regex *regexp.Regexp, options *GrepOptions) int {
 	totalMatches := 0
 	
-	err := filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
-		if err != nil {
-			return err
-		}
-		
+	stats, err := walkTreeContext(ctx, dir, &options.Walk, func(path string, info os.FileInfo) error {
 		if !info.IsDir() && (options.Text || isTextFile(path)) {
 			matches := grepInFile(path, regex, options)
 			totalMatches += matches
@@ -360,9 +227,10 @@ func grepInDirectory(dir string, regex *regexp.Regexp, options *GrepOptions) int
 		return nil
 	})
 	
-	if err != nil {
+	if err != nil && ctx.Err() == nil {
 		fmt.Printf("遍历目录错误: %v\n", err)
 	}
+	printWalkStats(stats)
 	
 	return totalMatches
 }
@@ -502,23 +370,27 @@ func printGrepResult(result *GrepResult, options *GrepOptions) {
 	fmt.Println(output.String())
 }
 
+// 文本文件扩展名及对应的语言
+var textExtensions = map[string]string{
+	".txt": "Text", ".md": "Markdown", ".go": "Go", ".py": "Python", ".java": "Java",
+	".c": "C", ".cpp": "C++", ".h": "C/C++ Header", ".hpp": "C++ Header",
+	".js": "JavaScript", ".ts": "TypeScript", ".html": "HTML", ".css": "CSS",
+	".json": "JSON", ".xml": "XML", ".yaml": "YAML", ".yml": "YAML",
+	".sh": "Shell", ".bash": "Shell", ".zsh": "Shell", ".fish": "Fish",
+	".ps1": "PowerShell", ".bat": "Batch", ".cmd": "Batch",
+	".sql": "SQL", ".php": "PHP", ".rb": "Ruby", ".rs": "Rust", ".swift": "Swift",
+	".kt": "Kotlin", ".scala": "Scala", ".r": "R", ".m": "Objective-C", ".pl": "Perl",
+	".lua": "Lua", ".vim": "Vim Script", ".emacs": "Emacs Lisp", ".cfg": "Config",
+	".conf": "Config", ".ini": "INI", ".toml": "TOML", ".properties": "Properties",
+	".log": "Log", ".csv": "CSV", ".tsv": "TSV",
+}
+
 // 判断是否为文本文件
 func isTextFile(filename string) bool {
 	// 基于文件扩展名的简单判断
 	ext := strings.ToLower(filepath.Ext(filename))
-	textExtensions := []string{
-		".txt", ".md", ".go", ".py", ".java", ".c", ".cpp", ".h", ".hpp",
-		".js", ".ts", ".html", ".css", ".json", ".xml", ".yaml", ".yml",
-		".sh", ".bash", ".zsh", ".fish", ".ps1", ".bat", ".cmd",
-		".sql", ".php", ".rb", ".rs", ".swift", ".kt", ".scala",
-		".r", ".m", ".pl", ".lua", ".vim", ".emacs", ".cfg", ".conf",
-		".ini", ".toml", ".properties", ".log", ".csv", ".tsv",
-	}
-	
-	for _, validExt := range textExtensions {
-		if ext == validExt {
-			return true
-		}
+	if _, ok := textExtensions[ext]; ok {
+		return true
 	}
 	
 	// 如果没有扩展名，检查文件内容
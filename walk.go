@@ -0,0 +1,356 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+// 目录遍历选项
+type WalkOptions struct {
+	FollowLinks bool // -L 跟随符号链接
+	Strict      bool // --strict 遇到第一个错误即停止遍历
+}
+
+// 遍历中被跳过的条目
+type WalkError struct {
+	Path string
+	Err  error
+}
+
+// 目录遍历统计
+type WalkStats struct {
+	BrokenLinks []string    // 目标不存在的符号链接
+	Loops       []string    // 指向祖先目录的符号链接（已跳过）
+	Errors      []WalkError // 无法读取而被跳过的条目
+}
+
+// 遍历回调，返回 filepath.SkipDir 可跳过当前目录
+type walkFunc func(path string, info os.FileInfo) error
+
+// 遍历器
+type walker struct {
+	ctx     context.Context
+	options *WalkOptions
+	fn      walkFunc
+	stats   *WalkStats
+	mu      sync.Mutex // 并发遍历时保护 stats 和回调
+}
+
+// 遍历目录树，支持跟随符号链接和循环检测。
+// 命令行给出的根路径如果是符号链接总会被跟随（类似 find -H）。
+func walkTree(root string, options *WalkOptions, fn walkFunc) (*WalkStats, error) {
+	return walkTreeContext(context.Background(), root, options, fn)
+}
+
+// 可取消的 walkTree，ctx 取消后停止遍历并返回 ctx.Err()
+func walkTreeContext(ctx context.Context, root string, options *WalkOptions, fn walkFunc) (*WalkStats, error) {
+	w := newWalker(ctx, options, fn)
+
+	info, err := os.Stat(root)
+	if err != nil {
+		return w.stats, err
+	}
+
+	err = w.walk(root, info, nil)
+	if err == filepath.SkipDir {
+		err = nil
+	}
+	return w.stats, err
+}
+
+// 创建遍历器
+func newWalker(ctx context.Context, options *WalkOptions, fn walkFunc) *walker {
+	if options == nil {
+		options = &WalkOptions{}
+	}
+
+	return &walker{
+		ctx:     ctx,
+		options: options,
+		fn:      fn,
+		stats:   &WalkStats{},
+	}
+}
+
+// 递归遍历，ancestors 为当前路径上已进入的目录，用于检测循环
+func (w *walker) walk(path string, info os.FileInfo, ancestors []os.FileInfo) error {
+	info, err := w.resolve(path, info, ancestors)
+	if info == nil {
+		return err
+	}
+
+	if err := w.fn(path, info); err != nil {
+		if info.IsDir() && err == filepath.SkipDir {
+			return nil
+		}
+		return err
+	}
+
+	if !info.IsDir() {
+		return nil
+	}
+
+	entries, err := w.readDir(path)
+	if err != nil {
+		return err
+	}
+
+	ancestors = append(ancestors, info)
+	for _, entry := range entries {
+		if err := w.ctx.Err(); err != nil {
+			return err
+		}
+
+		childPath := filepath.Join(path, entry.Name())
+		childInfo, err := entry.Info()
+		if err != nil {
+			if skipErr := w.skip(childPath, err); skipErr != nil {
+				return skipErr
+			}
+			continue
+		}
+
+		if err := w.walk(childPath, childInfo, ancestors); err != nil {
+			if err == filepath.SkipDir {
+				// 回调对文件返回 SkipDir 时跳过所在目录的剩余条目
+				return nil
+			}
+			return err
+		}
+	}
+
+	return nil
+}
+
+// 处理符号链接和循环检测，返回 nil 表示跳过该条目
+func (w *walker) resolve(path string, info os.FileInfo, ancestors []os.FileInfo) (os.FileInfo, error) {
+	if info.Mode()&os.ModeSymlink != 0 {
+		target, err := os.Stat(path)
+		if err != nil {
+			if os.IsNotExist(err) {
+				w.mu.Lock()
+				w.stats.BrokenLinks = append(w.stats.BrokenLinks, path)
+				w.mu.Unlock()
+				return nil, nil
+			}
+			return nil, w.skip(path, err)
+		}
+		if w.options.FollowLinks {
+			info = target
+		}
+	}
+
+	if info.IsDir() {
+		// os.SameFile 在类Unix系统上比较设备号和inode
+		for _, ancestor := range ancestors {
+			if os.SameFile(ancestor, info) {
+				w.mu.Lock()
+				w.stats.Loops = append(w.stats.Loops, path)
+				w.mu.Unlock()
+				return nil, nil
+			}
+		}
+	}
+
+	return info, nil
+}
+
+// 读取目录，出错时 os.ReadDir 仍会返回已读到的条目
+func (w *walker) readDir(path string) ([]os.DirEntry, error) {
+	entries, err := os.ReadDir(path)
+	if err != nil {
+		if skipErr := w.skip(path, err); skipErr != nil {
+			return nil, skipErr
+		}
+	}
+	return entries, nil
+}
+
+// 待读取的目录
+type dirTask struct {
+	path      string
+	ancestors []os.FileInfo
+}
+
+// 使用 workers 个工作线程并发遍历目录树。
+// 回调在锁内串行执行，但调用顺序不确定；父目录的回调总在其子条目之前。
+func walkTreeParallel(root string, options *WalkOptions, workers int, fn walkFunc) (*WalkStats, error) {
+	return walkTreeParallelContext(context.Background(), root, options, workers, fn)
+}
+
+// 可取消的 walkTreeParallel，ctx 取消后各工作线程不再读取新目录
+func walkTreeParallelContext(ctx context.Context, root string, options *WalkOptions, workers int, fn walkFunc) (*WalkStats, error) {
+	w := newWalker(ctx, options, fn)
+	if workers < 1 {
+		workers = 1
+	}
+
+	info, err := os.Stat(root)
+	if err != nil {
+		return w.stats, err
+	}
+	if err := fn(root, info); err != nil || !info.IsDir() {
+		if err == filepath.SkipDir {
+			err = nil
+		}
+		return w.stats, err
+	}
+
+	var (
+		queue    = []dirTask{{path: root, ancestors: []os.FileInfo{info}}}
+		pending  = 1 // 已入队但尚未处理完的目录数
+		firstErr error
+		queueMu  sync.Mutex
+		cond     = sync.NewCond(&queueMu)
+		wg       sync.WaitGroup
+	)
+
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for {
+				queueMu.Lock()
+				for len(queue) == 0 && pending > 0 && firstErr == nil {
+					cond.Wait()
+				}
+				if err := ctx.Err(); err != nil && firstErr == nil {
+					firstErr = err
+					cond.Broadcast()
+				}
+				if len(queue) == 0 || firstErr != nil {
+					queueMu.Unlock()
+					return
+				}
+				task := queue[len(queue)-1]
+				queue = queue[:len(queue)-1]
+				queueMu.Unlock()
+
+				children, err := w.readTask(task)
+
+				queueMu.Lock()
+				if err != nil && firstErr == nil {
+					firstErr = err
+				}
+				queue = append(queue, children...)
+				pending += len(children) - 1
+				cond.Broadcast()
+				queueMu.Unlock()
+			}
+		}()
+	}
+
+	wg.Wait()
+	return w.stats, firstErr
+}
+
+// 读取一个目录并回调其中的条目，返回需要继续遍历的子目录
+func (w *walker) readTask(task dirTask) ([]dirTask, error) {
+	entries, err := w.readDir(task.path)
+	if err != nil {
+		return nil, err
+	}
+
+	var children []dirTask
+	for _, entry := range entries {
+		childPath := filepath.Join(task.path, entry.Name())
+		childInfo, err := entry.Info()
+		if err != nil {
+			if skipErr := w.skip(childPath, err); skipErr != nil {
+				return nil, skipErr
+			}
+			continue
+		}
+
+		childInfo, err = w.resolve(childPath, childInfo, task.ancestors)
+		if childInfo == nil {
+			if err != nil {
+				return nil, err
+			}
+			continue
+		}
+
+		w.mu.Lock()
+		err = w.fn(childPath, childInfo)
+		w.mu.Unlock()
+		if err == filepath.SkipDir {
+			if childInfo.IsDir() {
+				continue
+			}
+			// 对文件返回 SkipDir 时跳过所在目录的剩余条目
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		if childInfo.IsDir() {
+			ancestors := make([]os.FileInfo, len(task.ancestors), len(task.ancestors)+1)
+			copy(ancestors, task.ancestors)
+			children = append(children, dirTask{path: childPath, ancestors: append(ancestors, childInfo)})
+		}
+	}
+
+	return children, nil
+}
+
+// 记录无法读取的条目，严格模式下返回错误以终止遍历
+func (w *walker) skip(path string, err error) error {
+	if w.options.Strict {
+		return err
+	}
+	w.mu.Lock()
+	w.stats.Errors = append(w.stats.Errors, WalkError{Path: path, Err: err})
+	w.mu.Unlock()
+	return nil
+}
+
+// 解析目录遍历相关选项，返回是否识别了该参数
+func parseWalkOption(arg string, options *WalkOptions) bool {
+	switch arg {
+	case "-L", "--follow":
+		options.FollowLinks = true
+		return true
+	case "--strict":
+		options.Strict = true
+		return true
+	default:
+		return false
+	}
+}
+
+// 打印遍历中发现的异常链接，跳过的条目作为警告输出到标准错误
+func printWalkStats(stats *WalkStats) {
+	if stats == nil {
+		return
+	}
+
+	if len(stats.BrokenLinks) > 0 {
+		fmt.Printf("断开的符号链接 (%d):\n", len(stats.BrokenLinks))
+		for _, path := range stats.BrokenLinks {
+			fmt.Printf("  %s\n", path)
+		}
+	}
+
+	if len(stats.Loops) > 0 {
+		fmt.Printf("符号链接循环 (%d, 已跳过):\n", len(stats.Loops))
+		for _, path := range stats.Loops {
+			fmt.Printf("  %s\n", path)
+		}
+	}
+
+	printWalkWarnings(stats)
+}
+
+// 将遍历中跳过的条目作为警告输出到标准错误
+func printWalkWarnings(stats *WalkStats) {
+	if stats != nil && len(stats.Errors) > 0 {
+		fmt.Fprintf(os.Stderr, "警告: %d 个条目无法读取，已跳过 (使用 --strict 在首个错误处停止):\n", len(stats.Errors))
+		for _, walkErr := range stats.Errors {
+			fmt.Fprintf(os.Stderr, "  %v\n", walkErr.Err)
+		}
+	}
+}